@@ -1,23 +1,213 @@
 package config
 
 import (
+	"fmt"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type AIConfig struct {
-	BaseURL string
-	APIKey  string
-	Model   string
+	Provider    string
+	BaseURL     string
+	APIKey      string
+	Model       string
+	Temperature *float64
+	MaxTokens   int64
+	Variants    int
+	// Timeout bounds every model call; MaxAttempts calls are made per
+	// endpoint with exponential backoff from RetryBackoff up to
+	// MaxRetryBackoff between them
+	Timeout         time.Duration
+	MaxAttempts     int
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	// Fallbacks are tried in order when the primary model is unavailable
+	Fallbacks []ModelEndpoint
+	// AllowedBaseURLs are the endpoints organizations may select with their
+	// ai.base_url and ai.fallbacks settings besides BaseURL and the
+	// endpoints of Fallbacks. They are called with APIKey.
+	AllowedBaseURLs []string
+	// BreakerFailures consecutive failures open an endpoint's circuit
+	// breaker for BreakerCooldown; zero disables the breaker
+	BreakerFailures int
+	BreakerCooldown time.Duration
 }
 
 func LoadAIConfig() *AIConfig {
+	fallbacks, _ := ParseModelEndpoints(getEnv("AI_FALLBACKS", ""))
 	return &AIConfig{
-		BaseURL: getEnv("OPENAI_BASE_URL", "http://localhost:11434/v1"),
-		APIKey:  getEnv("OPENAI_API_KEY", "ollama"),
-		Model:   getEnv("OPENAI_MODEL", "llama3.2"),
+		Provider:        getEnv("AI_PROVIDER", "openai"),
+		BaseURL:         getEnv("OPENAI_BASE_URL", "http://localhost:11434/v1"),
+		APIKey:          getEnv("OPENAI_API_KEY", "ollama"),
+		Model:           getEnv("OPENAI_MODEL", "llama3.2"),
+		Temperature:     getEnvFloat("OPENAI_TEMPERATURE"),
+		MaxTokens:       int64(getEnvInt("OPENAI_MAX_TOKENS", 0)),
+		Variants:        getEnvInt("AI_VARIANTS", 3),
+		Timeout:         getEnvDuration("AI_TIMEOUT", time.Minute),
+		MaxAttempts:     getEnvInt("AI_MAX_ATTEMPTS", 3),
+		RetryBackoff:    getEnvDuration("AI_RETRY_BACKOFF", 500*time.Millisecond),
+		MaxRetryBackoff: getEnvDuration("AI_MAX_RETRY_BACKOFF", 5*time.Second),
+		Fallbacks:       fallbacks,
+		AllowedBaseURLs: splitList(getEnv("AI_ALLOWED_BASE_URLS", "")),
+		BreakerFailures: getEnvInt("AI_BREAKER_FAILURES", 5),
+		BreakerCooldown: getEnvDuration("AI_BREAKER_COOLDOWN", 30*time.Second),
 	}
 }
 
+// AllowsBaseURL reports whether calls may be sent to baseURL, i.e. it is
+// empty (the primary endpoint) or configured by the operator. Trailing
+// slashes are ignored.
+func (c *AIConfig) AllowsBaseURL(baseURL string) bool {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if baseURL == "" || baseURL == strings.TrimRight(c.BaseURL, "/") {
+		return true
+	}
+	for _, allowed := range c.AllowedBaseURLs {
+		if baseURL == strings.TrimRight(allowed, "/") {
+			return true
+		}
+	}
+	for _, fallback := range c.Fallbacks {
+		if baseURL == strings.TrimRight(fallback.BaseURL, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ModelEndpoint is a model on an OpenAI-compatible endpoint. An empty
+// BaseURL means the primary endpoint.
+type ModelEndpoint struct {
+	Model   string `json:"model"`
+	BaseURL string `json:"base_url,omitempty"`
+}
+
+// ParseModelEndpoints parses a comma separated list of model or
+// model=baseURL entries, for example
+// "llama3.1,gpt-4o-mini=https://api.openai.com/v1"
+func ParseModelEndpoints(value string) ([]ModelEndpoint, error) {
+	var endpoints []ModelEndpoint
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		model, baseURL, _ := strings.Cut(entry, "=")
+		endpoint := ModelEndpoint{Model: strings.TrimSpace(model), BaseURL: strings.TrimSpace(baseURL)}
+		if endpoint.Model == "" {
+			return nil, fmt.Errorf("missing model in %q", entry)
+		}
+		if endpoint.BaseURL != "" {
+			if parsed, err := url.Parse(endpoint.BaseURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				return nil, fmt.Errorf("invalid endpoint URL in %q", entry)
+			}
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints, nil
+}
+
+// FewShotConfig holds the defaults for adding highly rated past messages to
+// prompts
+type FewShotConfig struct {
+	Enabled     bool
+	MaxExamples int
+	MaxTokens   int
+	MinRating   int
+}
+
+func LoadFewShotConfig() *FewShotConfig {
+	return &FewShotConfig{
+		Enabled:     getEnv("FEW_SHOT_ENABLED", "false") == "true",
+		MaxExamples: getEnvInt("FEW_SHOT_MAX_EXAMPLES", 3),
+		MaxTokens:   getEnvInt("FEW_SHOT_MAX_TOKENS", 600),
+		MinRating:   getEnvInt("FEW_SHOT_MIN_RATING", 4),
+	}
+}
+
+type SettingsConfig struct {
+	CacheTTL time.Duration
+}
+
+func LoadSettingsConfig() *SettingsConfig {
+	return &SettingsConfig{
+		CacheTTL: getEnvDuration("SETTINGS_CACHE_TTL", 30*time.Second),
+	}
+}
+
+type AuthConfig struct {
+	// AdminAPIKey grants access to every organization and to key
+	// management; leave empty to disable admin access
+	AdminAPIKey string
+	// LastUsedInterval throttles how often a key's last use is recorded
+	LastUsedInterval time.Duration
+}
+
+func LoadAuthConfig() *AuthConfig {
+	return &AuthConfig{
+		AdminAPIKey:      getEnv("ADMIN_API_KEY", ""),
+		LastUsedInterval: getEnvDuration("API_KEY_LAST_USED_INTERVAL", time.Minute),
+	}
+}
+
+// LimitConfig holds the default limits of an organization. Zero disables a
+// limit.
+type LimitConfig struct {
+	RequestsPerMinute int
+	Burst             int
+	DailyTokens       int64
+	MonthlyTokens     int64
+}
+
+func LoadLimitConfig() *LimitConfig {
+	return &LimitConfig{
+		RequestsPerMinute: getEnvInt("RATE_LIMIT_REQUESTS_PER_MINUTE", 60),
+		Burst:             getEnvInt("RATE_LIMIT_BURST", 10),
+		DailyTokens:       int64(getEnvInt("QUOTA_DAILY_TOKENS", 0)),
+		MonthlyTokens:     int64(getEnvInt("QUOTA_MONTHLY_TOKENS", 0)),
+	}
+}
+
+// ModelPrice is the USD price of a model per million tokens
+type ModelPrice struct {
+	PromptPerMillion     float64
+	CompletionPerMillion float64
+}
+
+type PricingConfig struct {
+	Prices map[string]ModelPrice
+}
+
+// LoadPricingConfig reads AI_PRICES, a comma separated list of
+// model=prompt/completion prices per million tokens, for example
+// "gpt-4o=2.5/10,llama3.2=0/0". Invalid entries are ignored.
+func LoadPricingConfig() *PricingConfig {
+	prices := map[string]ModelPrice{}
+	for _, entry := range strings.Split(getEnv("AI_PRICES", ""), ",") {
+		model, price, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		rawPrompt, rawCompletion, ok := strings.Cut(price, "/")
+		if !ok {
+			continue
+		}
+		prompt, err := strconv.ParseFloat(strings.TrimSpace(rawPrompt), 64)
+		if err != nil {
+			continue
+		}
+		completion, err := strconv.ParseFloat(strings.TrimSpace(rawCompletion), 64)
+		if err != nil {
+			continue
+		}
+		prices[strings.TrimSpace(model)] = ModelPrice{PromptPerMillion: prompt, CompletionPerMillion: completion}
+	}
+	return &PricingConfig{Prices: prices}
+}
+
 type DBConfig struct {
 	DBHost     string
 	DBUser     string
@@ -36,9 +226,114 @@ func LoadDBConfig() *DBConfig {
 	}
 }
 
+type BatchConfig struct {
+	DefaultConcurrency int
+	MaxConcurrency     int
+	MaxProfiles        int
+}
+
+func LoadBatchConfig() *BatchConfig {
+	return &BatchConfig{
+		DefaultConcurrency: getEnvInt("BATCH_CONCURRENCY", 4),
+		MaxConcurrency:     getEnvInt("BATCH_MAX_CONCURRENCY", 16),
+		MaxProfiles:        getEnvInt("BATCH_MAX_PROFILES", 500),
+	}
+}
+
+type JobConfig struct {
+	Workers      int
+	PollInterval time.Duration
+	MaxAttempts  int
+	RetryBackoff time.Duration
+	MaxBackoff   time.Duration
+	StaleAfter   time.Duration
+}
+
+func LoadJobConfig() *JobConfig {
+	return &JobConfig{
+		Workers:      getEnvInt("JOB_WORKERS", 2),
+		PollInterval: getEnvDuration("JOB_POLL_INTERVAL", time.Second),
+		MaxAttempts:  getEnvInt("JOB_MAX_ATTEMPTS", 5),
+		RetryBackoff: getEnvDuration("JOB_RETRY_BACKOFF", 5*time.Second),
+		MaxBackoff:   getEnvDuration("JOB_MAX_BACKOFF", 5*time.Minute),
+		StaleAfter:   getEnvDuration("JOB_STALE_AFTER", 10*time.Minute),
+	}
+}
+
+// TracingConfig configures OpenTelemetry tracing. Spans are exported over
+// OTLP/HTTP to OTLPEndpoint, e.g. http://localhost:4318 for a local
+// collector; when it is empty trace context is still propagated but no
+// spans are recorded. SampleRatio applies to traces that do not come with
+// a sampling decision from the caller.
+type TracingConfig struct {
+	ServiceName  string
+	OTLPEndpoint string
+	SampleRatio  float64
+}
+
+func LoadTracingConfig() *TracingConfig {
+	sampleRatio := 1.0
+	if value := getEnvFloat("OTEL_TRACES_SAMPLER_ARG"); value != nil {
+		sampleRatio = *value
+	}
+	return &TracingConfig{
+		ServiceName:  getEnv("OTEL_SERVICE_NAME", "mobilo-ai-go-server"),
+		OTLPEndpoint: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		SampleRatio:  sampleRatio,
+	}
+}
+
+// LogConfig configures the structured logs. Level is one of debug, info,
+// warn or error; SlowQuery is the duration above which database queries are
+// logged as slow.
+type LogConfig struct {
+	Level     string
+	SlowQuery time.Duration
+}
+
+func LoadLogConfig() *LogConfig {
+	return &LogConfig{
+		Level:     getEnv("LOG_LEVEL", "info"),
+		SlowQuery: getEnvDuration("LOG_SLOW_QUERY", 200*time.Millisecond),
+	}
+}
+
+// splitList splits a comma separated list, dropping empty entries
+func splitList(value string) []string {
+	var entries []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvFloat returns nil when the variable is unset or invalid
+func getEnvFloat(key string) *float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return &value
+	}
+	return nil
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
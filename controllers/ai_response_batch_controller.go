@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"fmt"
+	"go-server/config"
+	"go-server/helpers"
+	"go-server/jobs"
+	"go-server/middleware"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BatchGenerateMessagesRequest shares one business/goal block across many
+// customer profiles
+type BatchGenerateMessagesRequest struct {
+	OrganizationID    string                          `json:"organization_id" binding:"required,uuid"`
+	Channel           helpers.MessageChannel          `json:"channel" binding:"required,oneof=linkedin email sms whatsapp instagram twitter"`
+	AdditionalContext string                          `json:"additional_context,omitempty" binding:"len=0|max=500"`
+	BusinessInfo      helpers.BusinessInfoStruct      `json:"business_info" binding:"omitempty"`
+	Goal              helpers.GoalStruct              `json:"goal"`
+	PromptTemplate    string                          `json:"prompt_template,omitempty" binding:"max=120"`
+	CustomerProfiles  []helpers.CustomerProfileStruct `json:"customer_profiles" binding:"required,min=1,dive"`
+	Concurrency       int                             `json:"concurrency,omitempty" binding:"omitempty,min=1"`
+	Async             bool                            `json:"async,omitempty"`
+}
+
+type BatchItemResult struct {
+	Index      int                      `json:"index"`
+	Customer   string                   `json:"customer"`
+	Success    bool                     `json:"success"`
+	ID         string                   `json:"id,omitempty"`
+	JobID      string                   `json:"job_id,omitempty"`
+	Messages   []helpers.ChannelMessage `json:"messages,omitempty"`
+	UsedTokens int64                    `json:"used_tokens,omitempty"`
+	Error      string                   `json:"error,omitempty"`
+}
+
+type BatchGenerateMessagesResponse struct {
+	Total     int               `json:"total"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BatchItemResult `json:"results"`
+}
+
+func (s *Server) CreateAIResponseBatch(c *gin.Context) {
+	var request BatchGenerateMessagesRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	batchConfig := config.LoadBatchConfig()
+	if len(request.CustomerProfiles) > batchConfig.MaxProfiles {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request: at most %d customer profiles per batch", batchConfig.MaxProfiles)})
+		return
+	}
+
+	if !middleware.AuthorizeOrganization(c, request.OrganizationID) {
+		return
+	}
+
+	if err := helpers.ApplyOrganizationBusinessInfo(s.repos(c).Settings, request.OrganizationID, &request.BusinessInfo); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	inputs := make([]helpers.AiContext, len(request.CustomerProfiles))
+	for i, profile := range request.CustomerProfiles {
+		inputs[i] = helpers.AiContext{
+			Channel:           request.Channel,
+			AdditionalContext: request.AdditionalContext,
+			BusinessInfo:      request.BusinessInfo,
+			Goal:              request.Goal,
+			CustomerProfile:   profile,
+			PromptTemplate:    request.PromptTemplate,
+		}
+	}
+
+	response := BatchGenerateMessagesResponse{
+		Total:   len(inputs),
+		Results: make([]BatchItemResult, len(inputs)),
+	}
+
+	if request.Async {
+		for i, input := range inputs {
+			item := BatchItemResult{Index: i, Customer: input.CustomerProfile.Name}
+			job, err := jobs.Enqueue(s.repos(c).Jobs, request.OrganizationID, input)
+			if err != nil {
+				item.Error = err.Error()
+			} else {
+				item.Success = true
+				item.JobID = job.ID.String()
+			}
+			response.add(item)
+		}
+		c.JSON(http.StatusAccepted, response)
+		return
+	}
+
+	concurrency := request.Concurrency
+	if concurrency == 0 {
+		concurrency = batchConfig.DefaultConcurrency
+	}
+	concurrency = min(concurrency, batchConfig.MaxConcurrency)
+
+	for i, generation := range helpers.GenerateBatch(c.Request.Context(), s.Providers, s.Repositories, request.OrganizationID, inputs, concurrency) {
+		item := BatchItemResult{Index: i, Customer: inputs[i].CustomerProfile.Name}
+		if generation.Err != nil {
+			item.Error = generation.Err.Error()
+		} else {
+			item.Success = true
+			item.ID = generation.Result.ID
+			item.Messages = generation.Result.Response.Messages
+			item.UsedTokens = generation.Result.UsedTokens
+		}
+		response.add(item)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (r *BatchGenerateMessagesResponse) add(item BatchItemResult) {
+	r.Results[item.Index] = item
+	if item.Success {
+		r.Succeeded++
+	} else {
+		r.Failed++
+	}
+}
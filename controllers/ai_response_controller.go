@@ -1,23 +1,23 @@
 package controllers
 
 import (
+	"errors"
 	"go-server/helpers"
-	models "go-server/models"
-	"log"
+	"go-server/middleware"
+	"go-server/repositories"
+	"log/slog"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-// type CreateAIResponseRequest struct {
-// 	OrganizationID string `json:"organization_id" binding:"required"`
-// 	Query          string `json:"query" binding:"required"`
-// 	Response       string `json:"response" binding:"required"`
-// }
-
 // Request represents the incoming API request
-type GenerateMessagesRequest = helpers.AiContext
+type GenerateMessagesRequest struct {
+	OrganizationID string `json:"organization_id" binding:"required,uuid"`
+	helpers.AiContext
+}
 
 // Response represents the API response
 type GenerateMessagesResponse struct {
@@ -28,29 +28,8 @@ type GenerateMessagesResponse struct {
 	UsedTokens int64                    `json:"used_tokens,omitempty"`
 }
 
-type CreateAIResponseFeedbackRequest struct {
-	Feedback string `json:"feedback" binding:"required"`
-}
-
-func CreateAIResponse(c *gin.Context) {
-	// var request CreateAIResponseRequest
-	// if err := c.ShouldBindJSON(&request); err != nil {
-	// 	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-	// 	return
-	// }
-	// response := models.AIResponse{
-	// 	OrganizationID: request.OrganizationID,
-	// 	Query:          request.Query,
-	// 	Response:       request.Response,
-	// }
-	// if err := models.DB.Create(&response).Error; err != nil {
-	// 	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-	// 	return
-	// }
-
-	// Validate request body
+func (s *Server) CreateAIResponse(c *gin.Context) {
 	var input GenerateMessagesRequest
-	// TODO: Validate request body & parse it to AiContext
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, GenerateMessagesResponse{
 			Success: false,
@@ -58,84 +37,179 @@ func CreateAIResponse(c *gin.Context) {
 		})
 		return
 	}
-	// Convert request to BusinessContext
-	// input := helpers.AiContext{
-	// 	Channel:           req.Channel,
-	// 	AdditionalContext: req.AdditionalContext,
-	// 	BusinessInfo:      req.BusinessInfo,
-	// 	Goal:              req.Goal,
-	// 	CustomerProfile:   req.CustomerProfile,
-	// }
-
-	// input := helpers.AiContext{
-	// 	Channel:           "LinkedIn",
-	// 	AdditionalContext: "I want to book a product demo where I will be show them the product and how it works",
-	// 	BusinessInfo: helpers.BusinessInfoStruct{
-	// 		CompanyName:  "MobiloCard",
-	// 		Industry:     "Tech",
-	// 		CoreProducts: []string{"MobiloCard Pro", "MobiloCard Business"},
-	// 		ValueProps:   []string{"Increase efficiency", "Reduce costs", "Increase productivity", "Increase customer satisfaction"},
-	// 	},
-	// 	Goal: helpers.GoalStruct{
-	// 		Type:        "sales",
-	// 		Description: "Book product demo",
-	// 		Target:      "Schedule 15-minute call",
-	// 	},
-	// 	CustomerProfile: helpers.CustomerProfileStruct{
-	// 		Name:      "John Doe",
-	// 		Title:     "CTO",
-	// 		Company:   "Target Corp",
-	// 		Industry:  "Retail",
-	// 		Interests: []string{"AI", "Digital Transformation", "Customer retention", "Customer satisfaction"},
-	// 	},
-	// }
-
-	result, err := helpers.GenerateAIResponse(input)
+
+	if !middleware.AuthorizeOrganization(c, input.OrganizationID) {
+		return
+	}
+
+	if err := helpers.ApplyOrganizationBusinessInfo(s.repos(c).Settings, input.OrganizationID, &input.BusinessInfo); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	options, err := helpers.ResolveGenerationOptions(s.repos(c), input.OrganizationID, input.AiContext)
 	if err != nil {
+		c.JSON(generationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := helpers.GenerateAIResponse(c.Request.Context(), s.Providers, input.AiContext, options)
+	if err != nil {
+		c.JSON(generationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := helpers.SaveAIResponse(s.repos(c).AIResponses, input.OrganizationID, &result); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	log.Printf("Time taken to generate messages: %v", result.TimeTaken)
-	log.Printf("Tokens used: %v", result.UsedTokens)
+	slog.InfoContext(c.Request.Context(), "Generated messages",
+		"ai_response_id", result.ID, "model", result.Model, "channel", result.Channel,
+		"duration_ms", result.TimeTaken.Milliseconds(), "used_tokens", result.UsedTokens)
 
 	c.JSON(http.StatusCreated, result)
 }
 
-func GetOrganizationAIResponses(c *gin.Context) {
-	organizationId, err := uuid.Parse(c.Param("organizationId"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID"})
+// CreateAIResponseStream generates messages like CreateAIResponse but streams
+// the output as Server-Sent Events: "token" for every chunk of model output,
+// "message" for every message as soon as it is complete, then "done" with
+// usage and the stored response ID (or "error").
+func (s *Server) CreateAIResponseStream(c *gin.Context) {
+	var input GenerateMessagesRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, GenerateMessagesResponse{
+			Success: false,
+			Error:   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	if !middleware.AuthorizeOrganization(c, input.OrganizationID) {
 		return
 	}
-	var responses []models.AIResponse
-	if err := models.DB.Where(&models.AIResponse{OrganizationID: organizationId.String()}).Find(&responses).Error; err != nil {
+
+	if err := helpers.ApplyOrganizationBusinessInfo(s.repos(c).Settings, input.OrganizationID, &input.BusinessInfo); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, responses)
+
+	options, err := helpers.ResolveGenerationOptions(s.repos(c), input.OrganizationID, input.AiContext)
+	if err != nil {
+		c.JSON(generationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// The event stream headers are only set with the first event, so errors
+	// before it are still answered as JSON
+	send := func(event string, data interface{}) error {
+		if err := c.Request.Context().Err(); err != nil {
+			return err
+		}
+		if !c.Writer.Written() {
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Connection", "keep-alive")
+			c.Header("X-Accel-Buffering", "no")
+		}
+		c.SSEvent(event, data)
+		c.Writer.Flush()
+		return nil
+	}
+
+	result, err := helpers.GenerateAIResponseStream(c.Request.Context(), s.Providers, input.AiContext, options,
+		func(token string) error {
+			return send("token", gin.H{"text": token})
+		},
+		func(index int, message helpers.ChannelMessage) error {
+			return send("message", gin.H{"index": index, "message": message})
+		},
+	)
+	if err != nil {
+		if !c.Writer.Written() {
+			c.JSON(generationErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		send("error", gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := helpers.SaveAIResponse(s.repos(c).AIResponses, input.OrganizationID, &result); err != nil {
+		send("error", gin.H{"error": err.Error()})
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Streamed messages",
+		"ai_response_id", result.ID, "model", result.Model, "channel", result.Channel,
+		"duration_ms", result.TimeTaken.Milliseconds(), "used_tokens", result.UsedTokens)
+
+	send("done", gin.H{
+		"id":                result.ID,
+		"model":             result.Model,
+		"prompt_tokens":     result.PromptTokens,
+		"completion_tokens": result.CompletionTokens,
+		"used_tokens":       result.UsedTokens,
+		"time_taken":        result.TimeTaken,
+	})
+}
+
+// generationErrorStatus maps generation errors to HTTP status codes
+func generationErrorStatus(err error) int {
+	if errors.Is(err, helpers.ErrInvalidInput) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
 }
 
-func GetOrganizationAIResponse(c *gin.Context) {
+// GetOrganizationAIResponses lists an organization's AI responses a page at
+// a time. Query parameters: limit, cursor (next_cursor of the previous
+// page), channel, goal_type, customer_company, from and to (YYYY-MM-DD,
+// inclusive), min_rating and max_rating of any feedback, q (full-text search
+// over prompt and response) and sort (newest, oldest, tokens, relevance).
+func (s *Server) GetOrganizationAIResponses(c *gin.Context) {
 	organizationId, err := uuid.Parse(c.Param("organizationId"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID"})
 		return
 	}
-	id, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID"})
+
+	query := repositories.AIResponseQuery{
+		OrganizationID:  organizationId.String(),
+		Channel:         c.Query("channel"),
+		GoalType:        c.Query("goal_type"),
+		CustomerCompany: c.Query("customer_company"),
+		Search:          c.Query("q"),
+		Sort:            c.Query("sort"),
+		Cursor:          c.Query("cursor"),
+	}
+	for param, target := range map[string]*int{"limit": &query.Limit, "min_rating": &query.MinRating, "max_rating": &query.MaxRating} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		if *target, err = strconv.Atoi(value); err != nil || *target < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param})
+			return
+		}
+	}
+	var ok bool
+	if query.From, query.To, ok = dateRangeQuery(c); !ok {
 		return
 	}
-	var response models.AIResponse
-	if err := models.DB.Where(&models.AIResponse{OrganizationID: organizationId.String(), ID: id}).First(&response).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "AI response not found"})
+
+	page, err := s.repos(c).AIResponses.List(query)
+	if errors.Is(err, repositories.ErrInvalidQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, response)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
 }
 
-func CreateAIResponseFeedback(c *gin.Context) {
+func (s *Server) GetOrganizationAIResponse(c *gin.Context) {
 	organizationId, err := uuid.Parse(c.Param("organizationId"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID"})
@@ -146,19 +220,14 @@ func CreateAIResponseFeedback(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID"})
 		return
 	}
-	var request CreateAIResponseFeedbackRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	response, err := s.repos(c).AIResponses.Get(organizationId.String(), id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "AI response not found"})
 		return
 	}
-	feedback := models.AIResponseFeedback{
-		OrganizationID: organizationId.String(),
-		AIResponseID:   id,
-		Feedback:       request.Feedback,
-	}
-	if err := models.DB.Create(&feedback).Error; err != nil {
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusCreated, feedback)
+	c.JSON(http.StatusOK, response)
 }
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"errors"
+	"go-server/helpers"
+	"go-server/middleware"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CreateAPIKeyRequest struct {
+	OrganizationID string `json:"organization_id" binding:"required,uuid"`
+	Name           string `json:"name" binding:"required,max=100"`
+}
+
+// CreateAPIKey issues a key for an organization. The plaintext key is only
+// returned in this response.
+func (s *Server) CreateAPIKey(c *gin.Context) {
+	var request CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !middleware.AuthorizeOrganization(c, request.OrganizationID) {
+		return
+	}
+
+	issued, err := helpers.CreateAPIKey(s.repos(c).APIKeys, request.OrganizationID, request.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, issued)
+}
+
+func (s *Server) GetOrganizationAPIKeys(c *gin.Context) {
+	organizationId, err := uuid.Parse(c.Param("organizationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID"})
+		return
+	}
+
+	apiKeys, err := s.repos(c).APIKeys.List(organizationId.String())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, apiKeys)
+}
+
+// RotateAPIKey revokes a key and returns its replacement
+func (s *Server) RotateAPIKey(c *gin.Context) {
+	organizationId, id, ok := organizationResourceParams(c)
+	if !ok {
+		return
+	}
+
+	issued, err := helpers.RotateAPIKey(s.repos(c).APIKeys, organizationId, id)
+	if err != nil {
+		c.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, issued)
+}
+
+func (s *Server) RevokeAPIKey(c *gin.Context) {
+	organizationId, id, ok := organizationResourceParams(c)
+	if !ok {
+		return
+	}
+
+	apiKey, err := helpers.RevokeAPIKey(s.repos(c).APIKeys, organizationId, id)
+	if err != nil {
+		c.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, apiKey)
+}
+
+// organizationResourceParams parses the :organizationId and :id path
+// parameters
+func organizationResourceParams(c *gin.Context) (string, uuid.UUID, bool) {
+	organizationId, err := uuid.Parse(c.Param("organizationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID"})
+		return "", uuid.Nil, false
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID"})
+		return "", uuid.Nil, false
+	}
+	return organizationId.String(), id, true
+}
+
+func apiKeyErrorStatus(err error) int {
+	if errors.Is(err, helpers.ErrAPIKeyNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"errors"
+	"go-server/helpers"
+	"go-server/middleware"
+	webcrawlTool "go-server/tools/webcrawl-tool"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Upper bounds for the crawl requested by ExtractBusinessInfo
+const (
+	maxBusinessInfoCrawlPages = 25
+	maxBusinessInfoCrawlDepth = 3
+)
+
+type ExtractBusinessInfoRequest struct {
+	OrganizationID string `json:"organization_id" binding:"required_if=Save true,omitempty,uuid"`
+	URL            string `json:"url" binding:"required,max=2000"`
+	Save           bool   `json:"save,omitempty"`
+	MaxPages       int    `json:"max_pages,omitempty" binding:"omitempty,min=1"`
+	MaxDepth       int    `json:"max_depth,omitempty" binding:"omitempty,min=0"`
+}
+
+type ExtractBusinessInfoResponse struct {
+	helpers.BusinessInfoExtraction
+	Saved bool `json:"saved"`
+}
+
+// ExtractBusinessInfo crawls a company website and proposes a
+// BusinessInfoStruct for it, optionally saving it as the organization's
+// default business info for generation requests.
+func (s *Server) ExtractBusinessInfo(c *gin.Context) {
+	var request ExtractBusinessInfoRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	if request.OrganizationID == "" {
+		request.OrganizationID = middleware.OrganizationID(c)
+	}
+	if request.OrganizationID != "" && !middleware.AuthorizeOrganization(c, request.OrganizationID) {
+		return
+	}
+
+	options := webcrawlTool.DefaultOptions()
+	options.HTTPClient = s.CrawlClient
+	if request.MaxPages > 0 {
+		options.MaxPages = min(request.MaxPages, maxBusinessInfoCrawlPages)
+	}
+	if request.MaxDepth > 0 {
+		options.MaxDepth = min(request.MaxDepth, maxBusinessInfoCrawlDepth)
+	}
+
+	crawl, err := webcrawlTool.WebCrawl(c.Request.Context(), request.URL, options)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Failed to crawl website: " + err.Error()})
+		return
+	}
+
+	modelSettings, err := helpers.ResolveModelSettings(s.repos(c).Settings, request.OrganizationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ledger := helpers.UsageLedger{Repositories: s.repos(c), OrganizationID: request.OrganizationID}
+	extraction, err := helpers.ExtractBusinessInfo(c.Request.Context(), s.Providers.Provider(modelSettings.BaseURL), modelSettings, crawl, ledger)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, helpers.ErrInvalidInput) {
+			status = http.StatusUnprocessableEntity
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Extracted business info",
+		"pages", len(crawl.Pages), "duration_ms", extraction.TimeTaken.Milliseconds(), "used_tokens", extraction.UsedTokens)
+
+	response := ExtractBusinessInfoResponse{BusinessInfoExtraction: extraction}
+	if request.Save {
+		// Generations reject business info without a company name, so an
+		// incomplete extraction is returned for review instead of saved
+		if err := binding.Validator.ValidateStruct(extraction.BusinessInfo); err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":         "Extracted business info is incomplete: " + err.Error(),
+				"business_info": extraction.BusinessInfo,
+			})
+			return
+		}
+		if _, err := helpers.SaveOrganizationBusinessInfo(s.repos(c).Settings, request.OrganizationID, extraction.BusinessInfo); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		response.Saved = true
+	}
+
+	c.JSON(http.StatusOK, response)
+}
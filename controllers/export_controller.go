@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"fmt"
+	"go-server/helpers"
+	"go-server/middleware"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportFineTuningDataset streams accepted messages as a JSONL fine-tuning
+// dataset. Query parameters: format (chat or preference), organization_id,
+// channel, from and to (YYYY-MM-DD, inclusive) and min_rating. Only the
+// admin key may export across organizations.
+func (s *Server) ExportFineTuningDataset(c *gin.Context) {
+	query := helpers.DatasetQuery{
+		OrganizationID: c.Query("organization_id"),
+		Channel:        c.Query("channel"),
+		Format:         c.Query("format"),
+	}
+	if query.OrganizationID == "" {
+		query.OrganizationID = middleware.OrganizationID(c)
+	}
+	if query.OrganizationID != "" && !middleware.AuthorizeOrganization(c, query.OrganizationID) {
+		return
+	}
+
+	if rawMinRating := c.Query("min_rating"); rawMinRating != "" {
+		minRating, err := strconv.Atoi(rawMinRating)
+		if err != nil || minRating < 1 || minRating > 5 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid min_rating, expected 1-5"})
+			return
+		}
+		query.MinRating = minRating
+	}
+
+	var ok bool
+	if query.From, query.To, ok = dateRangeQuery(c); !ok {
+		return
+	}
+	if err := helpers.ValidateDatasetQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="fine-tuning-%s.jsonl"`, query.Format))
+	written, err := helpers.WriteFineTuningDataset(c.Writer, s.repos(c).Feedback, query)
+	if err != nil {
+		if !c.Writer.Written() {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Fine-tuning export failed", "examples", written, "error", err)
+		return
+	}
+	slog.InfoContext(c.Request.Context(), "Exported fine-tuning examples", "examples", written, "format", query.Format)
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"errors"
+	"go-server/helpers"
+	"go-server/repositories"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CreateAIResponseFeedbackRequest struct {
+	helpers.FeedbackInput
+}
+
+func (s *Server) CreateAIResponseFeedback(c *gin.Context) {
+	organizationId, id, ok := organizationResourceParams(c)
+	if !ok {
+		return
+	}
+	var request CreateAIResponseFeedbackRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	feedback, err := helpers.SaveAIResponseFeedback(s.repos(c).AIResponses, s.repos(c).Feedback, organizationId, id, request.FeedbackInput)
+	if err != nil {
+		c.JSON(feedbackErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, feedback)
+}
+
+// GetAIResponseFeedback lists the feedback on an AI response a page at a
+// time, with the query parameters of GetOrganizationFeedback
+func (s *Server) GetAIResponseFeedback(c *gin.Context) {
+	organizationId, id, ok := organizationResourceParams(c)
+	if !ok {
+		return
+	}
+	s.listFeedback(c, repositories.FeedbackFilter{OrganizationID: organizationId, AIResponseID: &id})
+}
+
+// GetAIResponseFeedbackSummary aggregates the feedback on an AI response,
+// optionally created between from and to (YYYY-MM-DD, inclusive)
+func (s *Server) GetAIResponseFeedbackSummary(c *gin.Context) {
+	organizationId, id, ok := organizationResourceParams(c)
+	if !ok {
+		return
+	}
+	s.summarizeFeedback(c, repositories.FeedbackFilter{OrganizationID: organizationId, AIResponseID: &id})
+}
+
+// GetOrganizationFeedback lists an organization's feedback a page at a time,
+// newest first. Query parameters: limit, cursor (next_cursor of the previous
+// page), from and to (YYYY-MM-DD, inclusive).
+func (s *Server) GetOrganizationFeedback(c *gin.Context) {
+	organizationId, err := uuid.Parse(c.Param("organizationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID"})
+		return
+	}
+	s.listFeedback(c, repositories.FeedbackFilter{OrganizationID: organizationId.String()})
+}
+
+// GetOrganizationFeedbackSummary aggregates an organization's feedback,
+// optionally created between from and to (YYYY-MM-DD, inclusive)
+func (s *Server) GetOrganizationFeedbackSummary(c *gin.Context) {
+	organizationId, err := uuid.Parse(c.Param("organizationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID"})
+		return
+	}
+	s.summarizeFeedback(c, repositories.FeedbackFilter{OrganizationID: organizationId.String()})
+}
+
+func (s *Server) listFeedback(c *gin.Context, filter repositories.FeedbackFilter) {
+	query := repositories.FeedbackQuery{FeedbackFilter: filter, Cursor: c.Query("cursor")}
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if query.Limit, err = strconv.Atoi(value); err != nil || query.Limit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+	}
+	var ok bool
+	if query.From, query.To, ok = dateRangeQuery(c); !ok {
+		return
+	}
+
+	page, err := s.repos(c).Feedback.List(query)
+	if errors.Is(err, repositories.ErrInvalidQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func (s *Server) summarizeFeedback(c *gin.Context, filter repositories.FeedbackFilter) {
+	var ok bool
+	if filter.From, filter.To, ok = dateRangeQuery(c); !ok {
+		return
+	}
+
+	summary, err := s.repos(c).Feedback.Summary(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+func feedbackErrorStatus(err error) int {
+	if errors.Is(err, helpers.ErrAIResponseNotFound) {
+		return http.StatusNotFound
+	}
+	return generationErrorStatus(err)
+}
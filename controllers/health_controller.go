@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"context"
+	aiTool "go-server/tools/ai-tool"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout bounds the database check of GetReadiness
+const readinessTimeout = 2 * time.Second
+
+type ReadinessResponse struct {
+	Ready    bool   `json:"ready"`
+	Database string `json:"database"`
+	Model    string `json:"model"`
+}
+
+// GetReadiness reports whether the server can handle generation traffic:
+// the database answers and the circuit breaker of the default model
+// endpoint is not open. It responds 503 otherwise so that load balancers
+// stop routing requests here. The route is public, so it only reports
+// status; endpoint details are served by GetModelEndpoints.
+func (s *Server) GetReadiness(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	response := ReadinessResponse{
+		Ready:    true,
+		Database: "ok",
+		Model:    "ok",
+	}
+	if err := s.Ping(ctx); err != nil {
+		slog.WarnContext(ctx, "Readiness check failed to reach the database", "error", err)
+		response.Ready = false
+		response.Database = "unavailable"
+	}
+	if !s.Providers.Ready() {
+		response.Ready = false
+		response.Model = "unavailable"
+	}
+
+	status := http.StatusOK
+	if !response.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, response)
+}
+
+type ModelEndpointsResponse struct {
+	Endpoints []aiTool.EndpointStatus `json:"endpoints"`
+}
+
+// GetModelEndpoints lists the circuit breaker state of every model
+// endpoint used so far, the default one first
+func (s *Server) GetModelEndpoints(c *gin.Context) {
+	c.JSON(http.StatusOK, ModelEndpointsResponse{Endpoints: s.Providers.Endpoints()})
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"errors"
+	"go-server/helpers"
+	"go-server/jobs"
+	"go-server/middleware"
+	models "go-server/models"
+	"go-server/repositories"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type GenerationJobResponse struct {
+	Job    models.GenerationJob `json:"job"`
+	Result *models.AIResponse   `json:"result,omitempty"`
+}
+
+func (s *Server) CreateGenerationJob(c *gin.Context) {
+	var input GenerateMessagesRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	if !middleware.AuthorizeOrganization(c, input.OrganizationID) {
+		return
+	}
+
+	if err := helpers.ApplyOrganizationBusinessInfo(s.repos(c).Settings, input.OrganizationID, &input.BusinessInfo); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := jobs.Enqueue(s.repos(c).Jobs, input.OrganizationID, input.AiContext)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (s *Server) GetGenerationJob(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID"})
+		return
+	}
+
+	job, err := s.repos(c).Jobs.Get(id)
+	if errors.Is(err, repositories.ErrNotFound) || (err == nil && !middleware.CanAccessOrganization(c, job.OrganizationID)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to load generation job", "job_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := GenerationJobResponse{Job: job}
+	if job.AIResponseID != nil {
+		result, err := s.repos(c).AIResponses.Get(job.OrganizationID, *job.AIResponseID)
+		switch {
+		case err == nil:
+			response.Result = &result
+		case !errors.Is(err, repositories.ErrNotFound):
+			slog.ErrorContext(c.Request.Context(), "Failed to load generation job result", "job_id", id, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"encoding/json"
+	"go-server/helpers"
+	"go-server/middleware"
+	linkedinTool "go-server/tools/linkedin-tool"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GenerateFromLinkedInRequest generates messages for a customer described by
+// a raw LinkedIn profile instead of a hand-written customer profile
+type GenerateFromLinkedInRequest struct {
+	OrganizationID    string                     `json:"organization_id" binding:"required,uuid"`
+	Channel           helpers.MessageChannel     `json:"channel" binding:"required,oneof=linkedin email sms whatsapp instagram twitter"`
+	AdditionalContext string                     `json:"additional_context,omitempty" binding:"len=0|max=500"`
+	BusinessInfo      helpers.BusinessInfoStruct `json:"business_info" binding:"omitempty"`
+	Goal              helpers.GoalStruct         `json:"goal"`
+	CustomerIndustry  string                     `json:"customer_industry,omitempty" binding:"max=200"`
+	PromptTemplate    string                     `json:"prompt_template,omitempty" binding:"max=120"`
+	LinkedInProfile   json.RawMessage            `json:"linkedin_profile" binding:"required"`
+}
+
+// linkedInParserOptions returns the default parser options overridden by
+// the organization's linkedin.* settings. The settings must be at least 1;
+// values stored before that was enforced are ignored rather than read as
+// the parser's "unlimited".
+func (s *Server) linkedInParserOptions(c *gin.Context, organizationID string) linkedinTool.ParserOptions {
+	options := linkedinTool.DefaultParserOptions()
+
+	settings, err := s.repos(c).Settings.Values(organizationID)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Failed to load LinkedIn parser settings", "error", err)
+		return options
+	}
+
+	for key, target := range map[string]*int{
+		helpers.SettingLinkedInMaxExperience: &options.MaxExperience,
+		helpers.SettingLinkedInMaxEducation:  &options.MaxEducation,
+		helpers.SettingLinkedInMaxActivity:   &options.MaxActivity,
+		helpers.SettingLinkedInMaxPosts:      &options.MaxPosts,
+	} {
+		if value := helpers.IntSetting(settings, key, 0); value > 0 {
+			*target = value
+		}
+	}
+	return options
+}
+
+func (s *Server) CreateAIResponseFromLinkedIn(c *gin.Context) {
+	var request GenerateFromLinkedInRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, GenerateMessagesResponse{
+			Success: false,
+			Error:   "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	if !middleware.AuthorizeOrganization(c, request.OrganizationID) {
+		return
+	}
+
+	if err := helpers.ApplyOrganizationBusinessInfo(s.repos(c).Settings, request.OrganizationID, &request.BusinessInfo); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile, err := helpers.ParseLinkedInDataForAIWithOptions(request.LinkedInProfile, s.linkedInParserOptions(c, request.OrganizationID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, GenerateMessagesResponse{
+			Success: false,
+			Error:   "Invalid LinkedIn profile: " + err.Error(),
+		})
+		return
+	}
+
+	customerProfile := helpers.LinkedInProfileToCustomerProfile(profile)
+	if industry := strings.TrimSpace(request.CustomerIndustry); industry != "" {
+		customerProfile.Industry = industry
+	}
+
+	input := helpers.AiContext{
+		Channel:           request.Channel,
+		AdditionalContext: request.AdditionalContext,
+		BusinessInfo:      request.BusinessInfo,
+		Goal:              request.Goal,
+		CustomerProfile:   customerProfile,
+		ProfileDetails:    helpers.LinkedInProfileDetails(profile),
+		PromptTemplate:    request.PromptTemplate,
+	}
+
+	options, err := helpers.ResolveGenerationOptions(s.repos(c), request.OrganizationID, input)
+	if err != nil {
+		c.JSON(generationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := helpers.GenerateAIResponse(c.Request.Context(), s.Providers, input, options)
+	if err != nil {
+		c.JSON(generationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := helpers.SaveAIResponse(s.repos(c).AIResponses, request.OrganizationID, &result); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Generated messages",
+		"ai_response_id", result.ID, "model", result.Model, "channel", result.Channel,
+		"duration_ms", result.TimeTaken.Milliseconds(), "used_tokens", result.UsedTokens)
+
+	c.JSON(http.StatusCreated, result)
+}
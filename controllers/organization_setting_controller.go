@@ -1,7 +1,11 @@
 package controllers
 
 import (
+	"errors"
+	"go-server/helpers"
+	"go-server/middleware"
 	models "go-server/models"
+	"go-server/repositories"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -22,19 +26,41 @@ type UpdateSettingRequest struct {
 	Value string `json:"value" binding:"required"`
 }
 
-func CreateOrganizationSetting(c *gin.Context) {
+// authorizeSettingKey aborts the request with 403 when the caller may not
+// write a setting: organizations cannot raise their own limits
+func authorizeSettingKey(c *gin.Context, key string) bool {
+	if helpers.IsAdminSetting(key) && !middleware.IsAdmin(c) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Only the admin API key may change " + key})
+		return false
+	}
+	return true
+}
+
+func (s *Server) CreateOrganizationSetting(c *gin.Context) {
 	var request CreateSettingRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !middleware.AuthorizeOrganization(c, request.OrganizationID) {
+		return
+	}
+	for _, setting := range request.Settings {
+		if !authorizeSettingKey(c, setting.Key) {
+			return
+		}
+		if err := helpers.ValidateOrganizationSetting(setting.Key, setting.Value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
 	for _, setting := range request.Settings {
 		setting := models.OrganizationSetting{
 			OrganizationID: request.OrganizationID,
 			Key:            setting.Key,
 			Value:          setting.Value,
 		}
-		if err := models.DB.Create(&setting).Error; err != nil {
+		if err := s.repos(c).Settings.Create(&setting); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -42,22 +68,22 @@ func CreateOrganizationSetting(c *gin.Context) {
 	c.JSON(http.StatusCreated, request.Settings)
 }
 
-func GetOrganizationSettings(c *gin.Context) {
+func (s *Server) GetOrganizationSettings(c *gin.Context) {
 	organizationId := c.Param("organizationId")
 	if organizationId == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Organization ID is required"})
 		return
 	}
 
-	var settings []models.OrganizationSetting
-	if err := models.DB.Where(&models.OrganizationSetting{OrganizationID: organizationId}).Find(&settings).Error; err != nil {
+	settings, err := s.repos(c).Settings.List(organizationId)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, settings)
 }
 
-func GetOrganizationSetting(c *gin.Context) {
+func (s *Server) GetOrganizationSetting(c *gin.Context) {
 	organizationId := c.Param("organizationId")
 	if organizationId == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Organization ID is required"})
@@ -66,16 +92,20 @@ func GetOrganizationSetting(c *gin.Context) {
 
 	key := c.Param("key")
 
-	var setting models.OrganizationSetting
-	if err := models.DB.First(&setting, "organization_id = ? AND key = ?", organizationId, key).Error; err != nil {
+	setting, err := s.repos(c).Settings.Get(organizationId, key)
+	if errors.Is(err, repositories.ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Setting not found"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, setting)
 }
 
-func UpdateOrganizationSetting(c *gin.Context) {
+func (s *Server) UpdateOrganizationSetting(c *gin.Context) {
 	organizationId := c.Param("organizationId")
 	if organizationId == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Organization ID is required"})
@@ -87,16 +117,27 @@ func UpdateOrganizationSetting(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !authorizeSettingKey(c, request.Key) {
+		return
+	}
+	if err := helpers.ValidateOrganizationSetting(request.Key, request.Value); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	var setting models.OrganizationSetting
-	if err := models.DB.First(&setting, "organization_id = ? AND key = ?", organizationId, request.Key).Error; err != nil {
+	setting, err := s.repos(c).Settings.Get(organizationId, request.Key)
+	if errors.Is(err, repositories.ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Setting not found"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	setting.Value = request.Value
 
-	if err := models.DB.Save(&setting).Error; err != nil {
+	if err := s.repos(c).Settings.Update(&setting); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"go-server/helpers"
+	"go-server/middleware"
+	models "go-server/models"
+	promptBuilderTool "go-server/tools/prompt-builder-tool"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreatePromptTemplateRequest struct {
+	// OrganizationID scopes the template to one organization; leave empty
+	// for a template every organization can use
+	OrganizationID string `json:"organization_id" binding:"omitempty,uuid"`
+	Name           string `json:"name" binding:"required,max=100,excludes=@"`
+	Description    string `json:"description" binding:"max=500"`
+	Body           string `json:"body" binding:"required"`
+}
+
+type PromptTemplatesResponse struct {
+	Templates []models.PromptTemplate      `json:"templates"`
+	Builtin   []promptBuilderTool.Template `json:"builtin"`
+}
+
+// CreatePromptTemplate stores a new version of a prompt template. The
+// version number is assigned automatically.
+func (s *Server) CreatePromptTemplate(c *gin.Context) {
+	var request CreatePromptTemplateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if request.OrganizationID == "" {
+		if !middleware.IsAdmin(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin API key required for global templates"})
+			return
+		}
+	} else if !middleware.AuthorizeOrganization(c, request.OrganizationID) {
+		return
+	}
+
+	if err := helpers.ValidatePromptTemplate(promptBuilderTool.Template{Name: request.Name, Body: request.Body}); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// stored versions of a built-in name continue after the built-in one
+	minVersion := 0
+	if builtin, ok := promptBuilderTool.BuiltinTemplate(request.Name, 0); ok {
+		minVersion = builtin.Version
+	}
+
+	template := models.PromptTemplate{
+		OrganizationID: request.OrganizationID,
+		Name:           request.Name,
+		Description:    request.Description,
+		Body:           request.Body,
+	}
+	if err := s.repos(c).PromptTemplates.Create(&template, minVersion); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, template)
+}
+
+// queryOrganizationID returns the organization_id query parameter, defaulting
+// to the caller's organization
+func queryOrganizationID(c *gin.Context) (string, bool) {
+	organizationId := c.Query("organization_id")
+	if organizationId == "" {
+		return middleware.OrganizationID(c), true
+	}
+	return organizationId, middleware.AuthorizeOrganization(c, organizationId)
+}
+
+// GetPromptTemplates lists the stored templates visible to an organization
+// (its own and the global ones) and the built-in templates
+func (s *Server) GetPromptTemplates(c *gin.Context) {
+	organizationId, ok := queryOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	templates, err := s.repos(c).PromptTemplates.List(organizationId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, PromptTemplatesResponse{
+		Templates: templates,
+		Builtin:   promptBuilderTool.BuiltinTemplates(),
+	})
+}
+
+// GetPromptTemplate returns the template an organization would use for a
+// name, optionally at a given version
+func (s *Server) GetPromptTemplate(c *gin.Context) {
+	version := 0
+	if rawVersion := c.Query("version"); rawVersion != "" {
+		var err error
+		if version, err = strconv.Atoi(rawVersion); err != nil || version < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+			return
+		}
+	}
+
+	organizationId, ok := queryOrganizationID(c)
+	if !ok {
+		return
+	}
+
+	template, err := helpers.FindPromptTemplate(s.repos(c).PromptTemplates, organizationId, c.Param("name"), version)
+	if err != nil {
+		status := generationErrorStatus(err)
+		if status == http.StatusBadRequest {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, template)
+}
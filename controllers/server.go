@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"go-server/repositories"
+	aiTool "go-server/tools/ai-tool"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Server holds what the API handlers depend on. Production servers use the
+// GORM repositories; tests can run the full router with
+// repositories.NewMemory() and aiTool.NewStaticResolver(aiTool.NewFakeProvider()).
+type Server struct {
+	repositories.Repositories
+	// Providers hands out the language model provider for each
+	// organization's endpoint
+	Providers *aiTool.Resolver
+	// CrawlClient fetches the websites business info is extracted from.
+	// When nil the crawler only connects to public addresses.
+	CrawlClient *http.Client
+}
+
+func NewServer(repos repositories.Repositories, providers *aiTool.Resolver) *Server {
+	return &Server{Repositories: repos, Providers: providers}
+}
+
+// repos returns the repositories bound to the context of the request
+func (s *Server) repos(c *gin.Context) repositories.Repositories {
+	return s.WithContext(c.Request.Context())
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"errors"
+	"go-server/helpers"
+	"go-server/middleware"
+	"go-server/repositories"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetOrganizationUsage returns the organization's token consumption for the
+// current day and month against its quotas
+func (s *Server) GetOrganizationUsage(c *gin.Context) {
+	organizationId, err := uuid.Parse(c.Param("organizationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID"})
+		return
+	}
+
+	usage, err := helpers.GetOrganizationUsage(s.repos(c), organizationId.String(), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, usage)
+}
+
+// GetUsageReport aggregates the usage ledger by the comma separated
+// group_by columns (organization, day, channel, model, operation) between
+// from and to (YYYY-MM-DD, inclusive), as JSON or as CSV with format=csv.
+// Only the admin key may report across organizations.
+func (s *Server) GetUsageReport(c *gin.Context) {
+	organizationId := c.Query("organization_id")
+	if organizationId == "" {
+		organizationId = middleware.OrganizationID(c)
+	}
+	if organizationId != "" && !middleware.AuthorizeOrganization(c, organizationId) {
+		return
+	}
+
+	query := repositories.UsageReportQuery{OrganizationID: organizationId}
+	if groupBy := c.Query("group_by"); groupBy != "" {
+		query.GroupBy = strings.Split(groupBy, ",")
+	}
+	var ok bool
+	if query.From, query.To, ok = dateRangeQuery(c); !ok {
+		return
+	}
+
+	rows, err := s.repos(c).Usage.Report(query)
+	if errors.Is(err, repositories.ErrInvalidQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		groups, _ := repositories.NormalizeUsageReportGroups(query.GroupBy)
+		c.Header("Content-Disposition", `attachment; filename="usage-report.csv"`)
+		c.Header("Content-Type", "text/csv")
+		if err := writeUsageReportCSV(c.Writer, groups, rows); err != nil {
+			c.Error(err)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+func writeUsageReportCSV(w io.Writer, groups []string, rows []repositories.UsageReportRow) error {
+	writer := csv.NewWriter(w)
+	header := append(append([]string{}, groups...), "calls", "prompt_tokens", "completion_tokens", "total_tokens", "cost")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		columns := map[string]string{
+			"organization": row.Organization,
+			"day":          row.Day,
+			"channel":      row.Channel,
+			"model":        row.Model,
+			"operation":    row.Operation,
+		}
+		record := make([]string, 0, len(header))
+		for _, group := range groups {
+			record = append(record, columns[group])
+		}
+		record = append(record,
+			strconv.FormatInt(row.Calls, 10),
+			strconv.FormatInt(row.PromptTokens, 10),
+			strconv.FormatInt(row.CompletionTokens, 10),
+			strconv.FormatInt(row.TotalTokens, 10),
+			strconv.FormatFloat(row.Cost, 'f', 6, 64),
+		)
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// dateRangeQuery parses the from and to query parameters (YYYY-MM-DD, both
+// inclusive) into a half-open range. Missing bounds are zero.
+func dateRangeQuery(c *gin.Context) (time.Time, time.Time, bool) {
+	var from, to time.Time
+	for param, target := range map[string]*time.Time{"from": &from, "to": &to} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		day, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + " date, expected YYYY-MM-DD"})
+			return time.Time{}, time.Time{}, false
+		}
+		*target = day
+	}
+	if !to.IsZero() {
+		to = to.AddDate(0, 0, 1)
+	}
+	return from, to, true
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"go-server/helpers"
+	"go-server/repositories"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+// runExport implements the export subcommand, writing a fine-tuning dataset
+// to a file or stdout:
+//
+//	go-server export -format chat -organization <id> -channel linkedin -from 2024-01-01 -to 2024-12-31 -out dataset.jsonl
+func runExport(repos repositories.Repositories, args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", helpers.DatasetFormatChat, "dataset format: chat or preference")
+	organizationID := flags.String("organization", "", "only export this organization")
+	channel := flags.String("channel", "", "only export this channel")
+	from := flags.String("from", "", "first day to export (YYYY-MM-DD)")
+	to := flags.String("to", "", "last day to export (YYYY-MM-DD)")
+	minRating := flags.Int("min-rating", 0, "lowest rating counted as positive (default 4)")
+	out := flags.String("out", "", "output file (default stdout)")
+	flags.Parse(args)
+
+	query := helpers.DatasetQuery{
+		OrganizationID: *organizationID,
+		Channel:        *channel,
+		Format:         *format,
+		MinRating:      *minRating,
+	}
+	if *from != "" {
+		day, err := time.Parse(time.DateOnly, *from)
+		if err != nil {
+			log.Fatalf("Invalid -from date: %v", err)
+		}
+		query.From = day
+	}
+	if *to != "" {
+		day, err := time.Parse(time.DateOnly, *to)
+		if err != nil {
+			log.Fatalf("Invalid -to date: %v", err)
+		}
+		query.To = day.AddDate(0, 0, 1)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("Failed to create output file: %v", err)
+		}
+		defer file.Close()
+		w = file
+	}
+	buffered := bufio.NewWriter(w)
+
+	written, err := helpers.WriteFineTuningDataset(buffered, repos.Feedback, query)
+	if err != nil {
+		log.Fatalf("Export failed: %v", err)
+	}
+	if err := buffered.Flush(); err != nil {
+		log.Fatalf("Export failed: %v", err)
+	}
+	log.Printf("Exported %d %s fine-tuning examples", written, query.Format)
+}
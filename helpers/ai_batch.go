@@ -0,0 +1,66 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"go-server/repositories"
+	aiTool "go-server/tools/ai-tool"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// BatchGeneration is the outcome of one input of a batch
+type BatchGeneration struct {
+	Result AIResponse
+	Err    error
+}
+
+// GenerateBatch generates and stores a response for every input using at
+// most concurrency parallel calls. A failing input does not stop the batch;
+// its error is reported in the matching BatchGeneration. Once the
+// organization's token quota is used up the remaining inputs fail with
+// ErrQuotaExceeded.
+func GenerateBatch(ctx context.Context, providers *aiTool.Resolver, repos repositories.Repositories, organizationID string, inputs []AiContext, concurrency int) []BatchGeneration {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	repos = repos.WithContext(ctx)
+
+	results := make([]BatchGeneration, len(inputs))
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, input := range inputs {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int, input AiContext) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			options, err := ResolveGenerationOptions(repos, organizationID, input)
+			if err != nil {
+				results[i] = BatchGeneration{Err: err}
+				return
+			}
+
+			// The quota is checked per generation as the batch itself may
+			// use it up
+			if _, err := CheckTokenQuota(repos, organizationID, time.Now()); errors.Is(err, ErrQuotaExceeded) {
+				results[i] = BatchGeneration{Err: err}
+				return
+			} else if err != nil {
+				slog.WarnContext(ctx, "Failed to check token quota, not enforcing it", "error", err)
+			}
+
+			result, err := GenerateAIResponse(ctx, providers, input, options)
+			if err == nil {
+				_, err = SaveAIResponse(repos.AIResponses, organizationID, &result)
+			}
+			results[i] = BatchGeneration{Result: result, Err: err}
+		}(i, input)
+	}
+
+	wg.Wait()
+	return results
+}
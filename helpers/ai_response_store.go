@@ -0,0 +1,59 @@
+package helpers
+
+import (
+	"fmt"
+	models "go-server/models"
+	"go-server/repositories"
+
+	"github.com/google/uuid"
+)
+
+// SaveAIResponse persists a generation result for the organization and sets
+// the stored ID on the result. Its usage is already in the ledger: every
+// model call is recorded as it is made, under the ID the generation
+// assigned to the result.
+func SaveAIResponse(aiResponses repositories.AIResponseRepository, organizationID string, result *AIResponse) (models.AIResponse, error) {
+	input, err := models.NewJSONB(result.Input)
+	if err != nil {
+		return models.AIResponse{}, fmt.Errorf("failed to encode input: %w", err)
+	}
+	messages, err := models.NewJSONB(result.Response.Messages)
+	if err != nil {
+		return models.AIResponse{}, fmt.Errorf("failed to encode messages: %w", err)
+	}
+	attempts, err := models.NewJSONB(result.Attempts)
+	if err != nil {
+		return models.AIResponse{}, fmt.Errorf("failed to encode attempts: %w", err)
+	}
+
+	record := models.AIResponse{
+		OrganizationID:   organizationID,
+		Channel:          string(result.Channel),
+		Input:            input,
+		Query:            result.Prompt,
+		PromptTemplate:   result.PromptTemplate,
+		PromptVersion:    result.PromptVersion,
+		Response:         result.RawResponse,
+		Messages:         messages,
+		PromptTokens:     result.PromptTokens,
+		CompletionTokens: result.CompletionTokens,
+		UsedTokens:       result.UsedTokens,
+		LatencyMs:        result.TimeTaken.Milliseconds(),
+		ModelName:        result.Model,
+		Attempts:         attempts,
+	}
+	if result.ID != "" {
+		// Keep the ID the usage ledger already refers to
+		id, err := uuid.Parse(result.ID)
+		if err != nil {
+			return models.AIResponse{}, fmt.Errorf("invalid AI response ID: %w", err)
+		}
+		record.ID = id
+	}
+	if err := aiResponses.Create(&record); err != nil {
+		return models.AIResponse{}, err
+	}
+
+	result.ID = record.ID.String()
+	return record, nil
+}
@@ -0,0 +1,68 @@
+package helpers
+
+import (
+	"go-server/repositories"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSaveAIResponsePersistsGeneration(t *testing.T) {
+	repos := repositories.NewMemory()
+	const organizationID = "6f1c1f53-9d43-4c4e-9a67-2f0b8f0f8a01"
+
+	result := AIResponse{
+		Input: AiContext{
+			Channel:         Email,
+			Goal:            GoalStruct{Type: "sales", Description: "Book product demo", Target: "Schedule a call"},
+			CustomerProfile: CustomerProfileStruct{Name: "Jane Doe", Title: "CTO", Company: "Target Corp"},
+		},
+		Prompt:           "Write to Jane",
+		PromptTemplate:   "default",
+		PromptVersion:    3,
+		Response:         GeneratedMessages{Messages: []ChannelMessage{{MessageText: "Hi Jane", Score: 0.9}}},
+		RawResponse:      `{"messages":[{"message":"Hi Jane","score":0.9}]}`,
+		Channel:          Email,
+		Model:            "gpt-4o",
+		PromptTokens:     120,
+		CompletionTokens: 30,
+		UsedTokens:       150,
+		TimeTaken:        1500 * time.Millisecond,
+		Attempts:         []GenerationAttempt{{Model: "gpt-4o", UsedTokens: 150, LatencyMs: 1500}},
+	}
+
+	record, err := SaveAIResponse(repos.AIResponses, organizationID, &result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.ID != record.ID.String() {
+		t.Errorf("expected the stored ID %s on the result, got %q", record.ID, result.ID)
+	}
+
+	stored, err := repos.AIResponses.Get(organizationID, uuid.MustParse(result.ID))
+	if err != nil {
+		t.Fatalf("expected the response to be stored: %v", err)
+	}
+	if stored.Channel != "email" || stored.Query != result.Prompt || stored.Response != result.RawResponse ||
+		stored.PromptTemplate != "default" || stored.PromptVersion != 3 || stored.ModelName != "gpt-4o" {
+		t.Errorf("unexpected stored generation %+v", stored)
+	}
+	if stored.PromptTokens != 120 || stored.CompletionTokens != 30 || stored.UsedTokens != 150 || stored.LatencyMs != 1500 {
+		t.Errorf("expected usage 120/30/150 in 1500ms, got %d/%d/%d in %dms",
+			stored.PromptTokens, stored.CompletionTokens, stored.UsedTokens, stored.LatencyMs)
+	}
+
+	var input AiContext
+	if err := stored.Input.Decode(&input); err != nil || input.CustomerProfile.Name != "Jane Doe" {
+		t.Errorf("expected the input to round-trip, got %+v (%v)", input, err)
+	}
+	var messages []ChannelMessage
+	if err := stored.Messages.Decode(&messages); err != nil || len(messages) != 1 || messages[0].MessageText != "Hi Jane" {
+		t.Errorf("expected the parsed messages to be stored, got %+v (%v)", messages, err)
+	}
+	var attempts []GenerationAttempt
+	if err := stored.Attempts.Decode(&attempts); err != nil || len(attempts) != 1 || attempts[0].Model != "gpt-4o" {
+		t.Errorf("expected the attempts to be stored, got %+v (%v)", attempts, err)
+	}
+}
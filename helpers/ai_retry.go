@@ -0,0 +1,168 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-server/config"
+	"go-server/metrics"
+	models "go-server/models"
+	aiTool "go-server/tools/ai-tool"
+	"go-server/tracing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GenerationAttempt is one model call made for a generation
+type GenerationAttempt struct {
+	Model      string `json:"model"`
+	BaseURL    string `json:"base_url,omitempty"`
+	Error      string `json:"error,omitempty"`
+	UsedTokens int64  `json:"used_tokens,omitempty"`
+	LatencyMs  int64  `json:"latency_ms"`
+}
+
+// permanentError marks a failure that must be neither retried nor failed
+// over, e.g. once streamed output has reached the client
+type permanentError struct {
+	error
+}
+
+func (err permanentError) Unwrap() error {
+	return err.error
+}
+
+// generationCall makes one model call with the model and endpoint of options
+type generationCall func(ctx context.Context, provider aiTool.Provider, options GenerationOptions) (AIResponse, error)
+
+// endpoints lists the primary model followed by its fallbacks
+func (o GenerationOptions) endpoints() []config.ModelEndpoint {
+	endpoints := []config.ModelEndpoint{{Model: o.Model.Model, BaseURL: o.Model.BaseURL}}
+	for _, fallback := range o.Model.Fallbacks {
+		if fallback.BaseURL == "" {
+			fallback.BaseURL = o.Model.BaseURL
+		}
+		endpoints = append(endpoints, fallback)
+	}
+	return endpoints
+}
+
+// generateWithPolicy makes call against the primary model and then each
+// fallback in turn. Every call is bounded by AI_TIMEOUT and retried up to
+// AI_MAX_ATTEMPTS times with exponential backoff while it fails with a
+// retryable error or returns unparseable output; other errors move on to
+// the next fallback. The result lists every attempt and carries the token
+// counts of the successful call. Every attempt is traced, recorded in the
+// model call metrics of channel and written to the usage ledger with its
+// own model and tokens, whether it succeeded or not. Ledger rows carry the
+// ID the result is saved under.
+func generateWithPolicy(ctx context.Context, providers *aiTool.Resolver, channel MessageChannel, options GenerationOptions, call generationCall) (AIResponse, error) {
+	policy := config.LoadAIConfig()
+	start := time.Now()
+	responseID := uuid.New()
+
+	var attempts []GenerationAttempt
+	var lastErr error
+	for _, endpoint := range options.endpoints() {
+		endpointOptions := options
+		endpointOptions.Model.Model = endpoint.Model
+		endpointOptions.Model.BaseURL = endpoint.BaseURL
+		provider := providers.Provider(endpoint.BaseURL)
+
+		for try := 0; try < max(policy.MaxAttempts, 1); try++ {
+			if try > 0 {
+				if err := sleepContext(ctx, Backoff(policy.RetryBackoff, policy.MaxRetryBackoff, try)); err != nil {
+					return AIResponse{Attempts: attempts}, fmt.Errorf("failed to generate messages: %w", err)
+				}
+			}
+
+			model := endpoint.Model
+			if model == "" {
+				model = provider.Model()
+			}
+			callCtx, cancel := context.WithTimeout(ctx, policy.Timeout)
+			callCtx, span := tracing.StartLLMCall(callCtx, model, string(channel))
+			callStart := time.Now()
+			result, err := call(callCtx, provider, endpointOptions)
+			latency := time.Since(callStart)
+			tracing.EndLLMCall(span, result.PromptTokens, result.CompletionTokens, err)
+			cancel()
+
+			attempt := GenerationAttempt{
+				Model:      model,
+				BaseURL:    endpoint.BaseURL,
+				UsedTokens: result.UsedTokens,
+				LatencyMs:  latency.Milliseconds(),
+			}
+			metrics.ObserveLLMCall(model, string(channel), latency, result.PromptTokens, result.CompletionTokens, err)
+			options.Ledger.Record(ctx, models.UsageRecord{
+				AIResponseID:     &responseID,
+				Operation:        models.UsageOperationGeneration,
+				Channel:          string(channel),
+				ModelName:        model,
+				PromptTokens:     result.PromptTokens,
+				CompletionTokens: result.CompletionTokens,
+				TotalTokens:      result.UsedTokens,
+			})
+			if err == nil {
+				result.ID = responseID.String()
+				result.Attempts = append(attempts, attempt)
+				result.TimeTaken = time.Since(start)
+				return result, nil
+			}
+
+			attempt.Error = err.Error()
+			attempts = append(attempts, attempt)
+			lastErr = err
+
+			var permanent permanentError
+			if ctx.Err() != nil || errors.As(err, &permanent) {
+				return AIResponse{Attempts: attempts}, fmt.Errorf("failed to generate messages: %w", err)
+			}
+			if !aiTool.IsRetryable(err) {
+				break
+			}
+		}
+	}
+	return AIResponse{Attempts: attempts}, fmt.Errorf("failed to generate messages after %d attempts: %w", len(attempts), lastErr)
+}
+
+// recordGenerationFailure counts a failed generation by its cause. Callers
+// that went away are not counted.
+func recordGenerationFailure(err error) {
+	switch {
+	case errors.Is(err, context.Canceled):
+	case errors.Is(err, ErrInvalidInput):
+		metrics.GenerationFailed(metrics.FailureValidation)
+	case errors.Is(err, ErrInvalidOutput):
+		metrics.GenerationFailed(metrics.FailureParse)
+	default:
+		metrics.GenerationFailed(metrics.FailureProvider)
+	}
+}
+
+// Backoff returns the exponential delay before the given retry (1-based),
+// starting at base and capped at max
+func Backoff(base, max time.Duration, retry int) time.Duration {
+	delay := base
+	for i := 1; i < retry; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	return min(delay, max)
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
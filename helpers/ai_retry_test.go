@@ -0,0 +1,269 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"go-server/config"
+	models "go-server/models"
+	"go-server/repositories"
+	aiTool "go-server/tools/ai-tool"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// modelProvider answers every model with its own provider
+type modelProvider map[string]aiTool.Provider
+
+func (p modelProvider) Model() string {
+	return "fake"
+}
+
+func (p modelProvider) Complete(ctx context.Context, request aiTool.CompletionRequest) (aiTool.CompletionResponse, error) {
+	return p[request.Model].Complete(ctx, request)
+}
+
+func (p modelProvider) Stream(ctx context.Context, request aiTool.CompletionRequest, onDelta func(delta string) error) (aiTool.CompletionResponse, error) {
+	return p[request.Model].Stream(ctx, request, onDelta)
+}
+
+func newModelProvider(contents map[string]string) modelProvider {
+	provider := modelProvider{}
+	for model, content := range contents {
+		fake := aiTool.NewFakeProvider()
+		fake.Content = content
+		provider[model] = fake
+	}
+	return provider
+}
+
+// stalledProvider never answers before the call is cancelled
+type stalledProvider struct{}
+
+func (stalledProvider) Model() string {
+	return "stalled"
+}
+
+func (stalledProvider) Complete(ctx context.Context, request aiTool.CompletionRequest) (aiTool.CompletionResponse, error) {
+	<-ctx.Done()
+	return aiTool.CompletionResponse{}, ctx.Err()
+}
+
+func (p stalledProvider) Stream(ctx context.Context, request aiTool.CompletionRequest, onDelta func(delta string) error) (aiTool.CompletionResponse, error) {
+	return p.Complete(ctx, request)
+}
+
+// recordingUsage keeps the ledger records it stores
+type recordingUsage struct {
+	repositories.UsageRepository
+	records []models.UsageRecord
+}
+
+func (usage *recordingUsage) Create(record *models.UsageRecord) error {
+	usage.records = append(usage.records, *record)
+	return usage.UsageRepository.Create(record)
+}
+
+const testMessagesContent = `{"messages":[{"message":"Hi Jane, quick demo?","score":0.9,"reasoning":"short"}]}`
+
+func usageByModel(t *testing.T, repos repositories.Repositories, organizationID string) map[string]repositories.UsageReportRow {
+	t.Helper()
+	rows, err := repos.Usage.Report(repositories.UsageReportQuery{OrganizationID: organizationID, GroupBy: []string{"model"}})
+	if err != nil {
+		t.Fatalf("failed to report usage: %v", err)
+	}
+	byModel := map[string]repositories.UsageReportRow{}
+	for _, row := range rows {
+		byModel[row.Model] = row
+	}
+	return byModel
+}
+
+func TestGenerateAIResponseRecordsEveryCallInTheLedger(t *testing.T) {
+	const organizationID = "org-ledger"
+	t.Setenv("AI_MAX_ATTEMPTS", "2")
+	t.Setenv("AI_PRICES", "primary=1000000/1000000,backup=0/2000000")
+	provider := newModelProvider(map[string]string{"primary": "not json", "backup": testMessagesContent})
+	repos := repositories.NewMemory()
+
+	options := testGenerationOptions(t, "primary")
+	options.Model.Fallbacks = []config.ModelEndpoint{{Model: "backup"}}
+	options.Ledger = UsageLedger{Repositories: repos, OrganizationID: organizationID}
+
+	result, err := GenerateAIResponse(context.Background(), aiTool.NewStaticResolver(provider), testAiContext(), options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Attempts) != 3 || result.Model != "backup" || result.UsedTokens != result.Attempts[2].UsedTokens {
+		t.Fatalf("expected two failed primary attempts and the backup's tokens only, got %d tokens for %+v", result.UsedTokens, result.Attempts)
+	}
+
+	usage := usageByModel(t, repos, organizationID)
+	primary, backup := usage["primary"], usage["backup"]
+	if primary.Calls != 2 || primary.TotalTokens != result.Attempts[0].UsedTokens+result.Attempts[1].UsedTokens {
+		t.Errorf("expected both failed calls on the primary model, got %+v", primary)
+	}
+	if primary.Cost != float64(primary.TotalTokens) {
+		t.Errorf("expected the failed calls to be priced at the primary price, got %v for %d tokens", primary.Cost, primary.TotalTokens)
+	}
+	if backup.Calls != 1 || backup.TotalTokens != result.UsedTokens || backup.Cost != float64(2*backup.CompletionTokens) {
+		t.Errorf("expected one backup call priced at the backup price, got %+v", backup)
+	}
+}
+
+func TestGenerateAIResponseLinksLedgerRowsToTheSavedResponse(t *testing.T) {
+	const organizationID = "org-ledger-link"
+	t.Setenv("AI_MAX_ATTEMPTS", "1")
+	provider := newModelProvider(map[string]string{"primary": "not json", "backup": testMessagesContent})
+	repos := repositories.NewMemory()
+	usage := &recordingUsage{UsageRepository: repos.Usage}
+	repos.Usage = usage
+
+	options := testGenerationOptions(t, "primary")
+	options.Model.Fallbacks = []config.ModelEndpoint{{Model: "backup"}}
+	options.Ledger = UsageLedger{Repositories: repos, OrganizationID: organizationID}
+
+	result, err := GenerateAIResponse(context.Background(), aiTool.NewStaticResolver(provider), testAiContext(), options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	record, err := SaveAIResponse(repos.AIResponses, organizationID, &result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(usage.records) != 2 {
+		t.Fatalf("expected a ledger row per call, got %d", len(usage.records))
+	}
+	for _, row := range usage.records {
+		if row.AIResponseID == nil || *row.AIResponseID != record.ID {
+			t.Errorf("expected the %s call to refer to the saved response %s, got %v", row.ModelName, record.ID, row.AIResponseID)
+		}
+	}
+	if result.ID != record.ID.String() {
+		t.Errorf("expected the result to keep its ID %s, got %s", record.ID, result.ID)
+	}
+
+	again, err := GenerateAIResponse(context.Background(), aiTool.NewStaticResolver(provider), testAiContext(), options)
+	if err != nil || again.ID == result.ID {
+		t.Errorf("expected every generation to get its own ID, got %q (%v)", again.ID, err)
+	}
+}
+
+func TestGenerateAIResponseRecordsFailedGenerations(t *testing.T) {
+	const organizationID = "org-ledger-failed"
+	t.Setenv("AI_MAX_ATTEMPTS", "1")
+	provider := newModelProvider(map[string]string{"primary": "not json", "backup": "still not json"})
+	repos := repositories.NewMemory()
+
+	options := testGenerationOptions(t, "primary")
+	options.Model.Fallbacks = []config.ModelEndpoint{{Model: "backup"}}
+	options.Ledger = UsageLedger{Repositories: repos, OrganizationID: organizationID}
+
+	if _, err := GenerateAIResponse(context.Background(), aiTool.NewStaticResolver(provider), testAiContext(), options); !errors.Is(err, ErrInvalidOutput) {
+		t.Fatalf("expected ErrInvalidOutput, got %v", err)
+	}
+	usage := usageByModel(t, repos, organizationID)
+	if usage["primary"].Calls != 1 || usage["backup"].Calls != 1 || usage["primary"].TotalTokens == 0 || usage["backup"].TotalTokens == 0 {
+		t.Errorf("expected a ledger row with tokens for every failed call, got %+v", usage)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		base, max time.Duration
+		retry     int
+		want      time.Duration
+	}{
+		{base: time.Second, max: time.Minute, retry: 1, want: time.Second},
+		{base: time.Second, max: time.Minute, retry: 3, want: 4 * time.Second},
+		{base: time.Second, max: 5 * time.Second, retry: 4, want: 5 * time.Second},
+		{base: time.Second, max: 5 * time.Second, retry: 100, want: 5 * time.Second},
+		{base: time.Minute, max: time.Second, retry: 1, want: time.Second},
+	}
+	for _, test := range tests {
+		if got := Backoff(test.base, test.max, test.retry); got != test.want {
+			t.Errorf("Backoff(%v, %v, %d): expected %v, got %v", test.base, test.max, test.retry, test.want, got)
+		}
+	}
+}
+
+func TestGenerateAIResponseRetriesAndFallsBackInOrder(t *testing.T) {
+	t.Setenv("AI_MAX_ATTEMPTS", "2")
+	primary, denied, backup, unused := aiTool.NewFakeProvider(), aiTool.NewFakeProvider(), aiTool.NewFakeProvider(), aiTool.NewFakeProvider()
+	primary.Err = errors.New("connection reset")
+	denied.Err = aiTool.ErrEndpointNotAllowed
+	backup.Content = testMessagesContent
+	unused.Content = testMessagesContent
+	provider := modelProvider{"primary": primary, "denied": denied, "backup": backup, "unused": unused}
+
+	options := testGenerationOptions(t, "primary")
+	options.Model.Fallbacks = []config.ModelEndpoint{{Model: "denied"}, {Model: "backup"}, {Model: "unused"}}
+
+	result, err := GenerateAIResponse(context.Background(), aiTool.NewStaticResolver(provider), testAiContext(), options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var called []string
+	for _, attempt := range result.Attempts {
+		called = append(called, attempt.Model)
+	}
+	if want := []string{"primary", "primary", "denied", "backup"}; !reflect.DeepEqual(called, want) {
+		t.Errorf("expected attempts on %v, got %v", want, called)
+	}
+	if result.Model != "backup" || result.Attempts[3].Error != "" || result.Attempts[0].Error == "" {
+		t.Errorf("expected the backup to answer after the failed attempts, got %s for %+v", result.Model, result.Attempts)
+	}
+	if len(denied.Requests()) != 1 || len(unused.Requests()) != 0 {
+		t.Errorf("expected a non-retryable error to move on at once and later fallbacks to stay unused, got %d and %d calls",
+			len(denied.Requests()), len(unused.Requests()))
+	}
+}
+
+func TestGenerateAIResponseGivesUpAfterEveryEndpoint(t *testing.T) {
+	t.Setenv("AI_MAX_ATTEMPTS", "3")
+	primary, backup := aiTool.NewFakeProvider(), aiTool.NewFakeProvider()
+	primary.Err = errors.New("connection reset")
+	backup.Err = errors.New("service unavailable")
+	provider := modelProvider{"primary": primary, "backup": backup}
+
+	options := testGenerationOptions(t, "primary")
+	options.Model.Fallbacks = []config.ModelEndpoint{{Model: "backup"}}
+
+	result, err := GenerateAIResponse(context.Background(), aiTool.NewStaticResolver(provider), testAiContext(), options)
+	if err == nil || !errors.Is(err, backup.Err) {
+		t.Fatalf("expected the last error, got %v", err)
+	}
+	if len(result.Attempts) != 6 || len(primary.Requests()) != 3 || len(backup.Requests()) != 3 {
+		t.Errorf("expected 3 attempts per endpoint, got %+v", result.Attempts)
+	}
+}
+
+func TestGenerateAIResponseBoundsEveryCallByTheTimeout(t *testing.T) {
+	t.Setenv("AI_MAX_ATTEMPTS", "2")
+	t.Setenv("AI_TIMEOUT", "20ms")
+	backup := aiTool.NewFakeProvider()
+	backup.Content = testMessagesContent
+	provider := modelProvider{"primary": stalledProvider{}, "backup": backup}
+
+	options := testGenerationOptions(t, "primary")
+	options.Model.Fallbacks = []config.ModelEndpoint{{Model: "backup"}}
+
+	start := time.Now()
+	result, err := GenerateAIResponse(context.Background(), aiTool.NewStaticResolver(provider), testAiContext(), options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected stalled calls to time out after 20ms, took %v", elapsed)
+	}
+	if len(result.Attempts) != 3 || result.Model != "backup" {
+		t.Fatalf("expected two timed out primary attempts before the backup, got %+v", result.Attempts)
+	}
+	for _, attempt := range result.Attempts[:2] {
+		if attempt.Model != "primary" || attempt.Error != context.DeadlineExceeded.Error() {
+			t.Errorf("expected the primary attempt to time out, got %+v", attempt)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	aiTool "go-server/tools/ai-tool"
+	"strings"
+	"testing"
+)
+
+func testAiContext() AiContext {
+	return AiContext{
+		Channel: Email,
+		BusinessInfo: BusinessInfoStruct{
+			CompanyName:  "MobiloCard",
+			Industry:     "Tech",
+			CoreProducts: []string{"MobiloCard Pro"},
+			ValueProps:   []string{"Increase efficiency"},
+		},
+		Goal: GoalStruct{Type: "sales", Description: "Book product demo", Target: "Schedule a call"},
+		CustomerProfile: CustomerProfileStruct{
+			Name: "Jane Doe", Title: "CTO", Company: "Target Corp", Industry: "Retail", Interests: []string{"AI"},
+		},
+	}
+}
+
+// testGenerationOptions asks for two messages from model without retry delays
+func testGenerationOptions(t *testing.T, model string) GenerationOptions {
+	t.Helper()
+	t.Setenv("AI_RETRY_BACKOFF", "1ms")
+	t.Setenv("AI_MAX_RETRY_BACKOFF", "1ms")
+	return GenerationOptions{Model: ModelSettings{Model: model, Variants: 2}}
+}
+
+func TestGenerateAIResponseParsesFakeProviderOutput(t *testing.T) {
+	provider := aiTool.NewFakeProvider()
+	provider.Content = `{"messages":[{"message":"Hi Jane, quick demo?","score":0.9,"reasoning":"short"},{"message":"Jane, can we talk?","score":0.7,"reasoning":"direct"}]}`
+
+	result, err := GenerateAIResponse(context.Background(), aiTool.NewStaticResolver(provider), testAiContext(), testGenerationOptions(t, "gpt-4o"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Response.Messages) != 2 || result.Response.Messages[0].MessageText != "Hi Jane, quick demo?" || result.Response.Messages[1].Score != 0.7 {
+		t.Errorf("expected the two fake messages, got %+v", result.Response.Messages)
+	}
+	if result.Channel != Email || result.Model != "gpt-4o" || result.RawResponse != provider.Content {
+		t.Errorf("expected an email response from gpt-4o with the raw output, got %q from %q", result.Channel, result.Model)
+	}
+
+	requests := provider.Requests()
+	if len(requests) != 1 || requests[0].Prompt != result.Prompt || !strings.Contains(result.Prompt, "MobiloCard") {
+		t.Fatalf("expected one request with the returned prompt, got %d", len(requests))
+	}
+	wantPrompt := int64(len(strings.Fields(result.Prompt)))
+	wantCompletion := int64(len(strings.Fields(provider.Content)))
+	if result.PromptTokens != wantPrompt || result.CompletionTokens != wantCompletion || result.UsedTokens != wantPrompt+wantCompletion {
+		t.Errorf("expected %d+%d tokens, got %d+%d=%d", wantPrompt, wantCompletion, result.PromptTokens, result.CompletionTokens, result.UsedTokens)
+	}
+	if len(result.Attempts) != 1 || result.Attempts[0].Error != "" || result.Attempts[0].UsedTokens != result.UsedTokens {
+		t.Errorf("expected one successful attempt, got %+v", result.Attempts)
+	}
+}
+
+func TestGenerateAIResponseRejectsUnparseableOutput(t *testing.T) {
+	provider := aiTool.NewFakeProvider()
+	provider.Content = `not json`
+	t.Setenv("AI_MAX_ATTEMPTS", "2")
+
+	result, err := GenerateAIResponse(context.Background(), aiTool.NewStaticResolver(provider), testAiContext(), testGenerationOptions(t, "gpt-4o"))
+	if !errors.Is(err, ErrInvalidOutput) {
+		t.Fatalf("expected ErrInvalidOutput, got %v", err)
+	}
+	if len(provider.Requests()) != 2 || len(result.Attempts) != 2 || result.Attempts[1].UsedTokens == 0 {
+		t.Errorf("expected two attempts with their tokens recorded, got %+v", result.Attempts)
+	}
+}
+
+func TestGenerateAIResponseValidatesInput(t *testing.T) {
+	provider := aiTool.NewFakeProvider()
+	input := testAiContext()
+	input.CustomerProfile.Name = ""
+
+	if _, err := GenerateAIResponse(context.Background(), aiTool.NewStaticResolver(provider), input, testGenerationOptions(t, "")); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput, got %v", err)
+	}
+	if len(provider.Requests()) != 0 {
+		t.Errorf("expected invalid input to never reach the provider")
+	}
+}
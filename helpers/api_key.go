@@ -0,0 +1,120 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-server/config"
+	models "go-server/models"
+	"go-server/repositories"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// apiKeyPrefix marks keys issued by this server so they are easy to spot in
+// configuration files and secret scanners
+const apiKeyPrefix = "mk_"
+
+// ErrInvalidAPIKey is returned for unknown, malformed or revoked keys
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// ErrAPIKeyNotFound is returned when a key does not exist in the organization
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+// IssuedAPIKey is a newly created key. Key holds the plaintext value, which
+// is only available at creation time.
+type IssuedAPIKey struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// HashAPIKey returns the stored representation of a plaintext key
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateAPIKey() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return apiKeyPrefix + hex.EncodeToString(secret), nil
+}
+
+func newAPIKey(organizationID string, name string) (models.APIKey, string, error) {
+	key, err := generateAPIKey()
+	if err != nil {
+		return models.APIKey{}, "", err
+	}
+	return models.APIKey{
+		OrganizationID: organizationID,
+		Name:           name,
+		Prefix:         key[:len(apiKeyPrefix)+8],
+		KeyHash:        HashAPIKey(key),
+	}, key, nil
+}
+
+// CreateAPIKey issues a new key for an organization
+func CreateAPIKey(apiKeys repositories.APIKeyRepository, organizationID string, name string) (IssuedAPIKey, error) {
+	apiKey, key, err := newAPIKey(organizationID, name)
+	if err != nil {
+		return IssuedAPIKey{}, err
+	}
+	if err := apiKeys.Create(&apiKey); err != nil {
+		return IssuedAPIKey{}, err
+	}
+	return IssuedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+// RotateAPIKey revokes an active key and issues a replacement with the same
+// name in one transaction
+func RotateAPIKey(apiKeys repositories.APIKeyRepository, organizationID string, id uuid.UUID) (IssuedAPIKey, error) {
+	apiKey, key, err := newAPIKey(organizationID, "")
+	if err != nil {
+		return IssuedAPIKey{}, err
+	}
+	if _, err := apiKeys.Rotate(organizationID, id, &apiKey); err != nil {
+		return IssuedAPIKey{}, apiKeyError(err)
+	}
+	return IssuedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+// RevokeAPIKey disables an active key
+func RevokeAPIKey(apiKeys repositories.APIKeyRepository, organizationID string, id uuid.UUID) (models.APIKey, error) {
+	apiKey, err := apiKeys.Revoke(organizationID, id)
+	return apiKey, apiKeyError(err)
+}
+
+func apiKeyError(err error) error {
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrAPIKeyNotFound
+	}
+	return err
+}
+
+// AuthenticateAPIKey returns the active key matching a plaintext key and
+// records its use
+func AuthenticateAPIKey(apiKeys repositories.APIKeyRepository, key string) (models.APIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return models.APIKey{}, ErrInvalidAPIKey
+	}
+
+	apiKey, err := apiKeys.FindActive(HashAPIKey(key))
+	if errors.Is(err, repositories.ErrNotFound) {
+		return models.APIKey{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return models.APIKey{}, err
+	}
+
+	now := time.Now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= config.LoadAuthConfig().LastUsedInterval {
+		apiKeys.Touch(&apiKey, now)
+	}
+	return apiKey, nil
+}
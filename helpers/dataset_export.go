@@ -0,0 +1,176 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	models "go-server/models"
+	"go-server/repositories"
+	"io"
+	"strings"
+	"time"
+)
+
+// Fine-tuning dataset formats
+const (
+	// DatasetFormatChat writes one OpenAI chat fine-tuning example per
+	// accepted message
+	DatasetFormatChat = "chat"
+	// DatasetFormatPreference writes chosen/rejected message pairs in the
+	// OpenAI preference fine-tuning format
+	DatasetFormatPreference = "preference"
+)
+
+// defaultDatasetMinRating is the lowest rating counted as positive feedback
+const defaultDatasetMinRating = 4
+
+// DatasetQuery selects the responses and feedback exported to a dataset.
+// Empty fields and zero times are not filtered on.
+type DatasetQuery struct {
+	OrganizationID string
+	Channel        string
+	From           time.Time
+	To             time.Time
+	Format         string
+	MinRating      int
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatExample struct {
+	Messages []chatMessage `json:"messages"`
+}
+
+type preferenceExample struct {
+	Input struct {
+		Messages []chatMessage `json:"messages"`
+	} `json:"input"`
+	PreferredOutput    []chatMessage `json:"preferred_output"`
+	NonPreferredOutput []chatMessage `json:"non_preferred_output"`
+}
+
+// ValidateDatasetQuery fills in defaults and checks the format
+func ValidateDatasetQuery(query *DatasetQuery) error {
+	if query.Format == "" {
+		query.Format = DatasetFormatChat
+	}
+	if query.Format != DatasetFormatChat && query.Format != DatasetFormatPreference {
+		return fmt.Errorf("%w: unknown dataset format %q", ErrInvalidInput, query.Format)
+	}
+	if query.MinRating == 0 {
+		query.MinRating = defaultDatasetMinRating
+	}
+	return nil
+}
+
+// WriteFineTuningDataset writes the dataset as JSONL and returns the number
+// of examples written. Chat examples pair the stored prompt with every
+// edited or up-voted message and with the selected message of positively
+// rated feedback. Preference examples pair each edited,
+// selected or up-voted message with every down-voted or unselected message
+// of the same response.
+func WriteFineTuningDataset(w io.Writer, feedback repositories.FeedbackRepository, query DatasetQuery) (int, error) {
+	if err := ValidateDatasetQuery(&query); err != nil {
+		return 0, err
+	}
+
+	encoder := json.NewEncoder(w)
+	written := 0
+	err := feedback.Dataset(repositories.FeedbackDatasetQuery{
+		OrganizationID: query.OrganizationID,
+		Channel:        query.Channel,
+		From:           query.From,
+		To:             query.To,
+	}, func(row repositories.FeedbackExample) error {
+		for _, example := range datasetExamples(row, query) {
+			if err := encoder.Encode(example); err != nil {
+				return fmt.Errorf("failed to write dataset: %w", err)
+			}
+			written++
+		}
+		return nil
+	})
+	return written, err
+}
+
+// datasetExamples turns one feedback row into dataset examples
+func datasetExamples(row repositories.FeedbackExample, query DatasetQuery) []interface{} {
+	var messages []ChannelMessage
+	if row.Messages.Decode(&messages) != nil {
+		return nil
+	}
+	var votes []MessageVote
+	if len(row.MessageVotes) > 0 {
+		row.MessageVotes.Decode(&votes)
+	}
+
+	// A selection only endorses a message on its own when the response was
+	// rated positively, but always ranks it above the other messages
+	positive := row.Rating != nil && *row.Rating >= query.MinRating
+	var chosen, rejected []string
+	if text := strings.TrimSpace(row.EditedText); text != "" {
+		chosen = append(chosen, text)
+	}
+	if (positive || query.Format == DatasetFormatPreference) && row.SelectedIndex != nil && *row.SelectedIndex < len(messages) {
+		chosen = append(chosen, messages[*row.SelectedIndex].MessageText)
+	}
+	for _, vote := range votes {
+		if vote.Index >= len(messages) {
+			continue
+		}
+		switch vote.Vote {
+		case models.FeedbackVoteUp:
+			chosen = append(chosen, messages[vote.Index].MessageText)
+		case models.FeedbackVoteDown:
+			rejected = append(rejected, messages[vote.Index].MessageText)
+		}
+	}
+	if row.SelectedIndex != nil && *row.SelectedIndex < len(messages) {
+		for i, message := range messages {
+			if i != *row.SelectedIndex {
+				rejected = append(rejected, message.MessageText)
+			}
+		}
+	}
+	chosen = uniqueStrings(chosen)
+	rejected = uniqueStrings(rejected)
+
+	prompt := chatMessage{Role: "user", Content: row.Query}
+	var examples []interface{}
+	switch query.Format {
+	case DatasetFormatChat:
+		for _, text := range chosen {
+			examples = append(examples, chatExample{Messages: []chatMessage{prompt, {Role: "assistant", Content: text}}})
+		}
+	case DatasetFormatPreference:
+		for _, preferred := range chosen {
+			for _, nonPreferred := range rejected {
+				if preferred == nonPreferred {
+					continue
+				}
+				var example preferenceExample
+				example.Input.Messages = []chatMessage{prompt}
+				example.PreferredOutput = []chatMessage{{Role: "assistant", Content: preferred}}
+				example.NonPreferredOutput = []chatMessage{{Role: "assistant", Content: nonPreferred}}
+				examples = append(examples, example)
+			}
+		}
+	}
+	return examples
+}
+
+// uniqueStrings drops empty and repeated values, keeping the first order
+func uniqueStrings(values []string) []string {
+	seen := map[string]bool{}
+	unique := values[:0]
+	for _, value := range values {
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		unique = append(unique, value)
+	}
+	return unique
+}
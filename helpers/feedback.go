@@ -0,0 +1,90 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	models "go-server/models"
+	"go-server/repositories"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ErrAIResponseNotFound is returned when feedback targets an AI response
+// that does not exist in the organization
+var ErrAIResponseNotFound = errors.New("AI response not found")
+
+// MessageVote is a thumbs up or down on one returned message
+type MessageVote struct {
+	Index int    `json:"index" binding:"min=0"`
+	Vote  string `json:"vote" binding:"required,oneof=up down"`
+}
+
+// FeedbackInput is the structured feedback on an AI response
+type FeedbackInput struct {
+	Feedback      string        `json:"feedback,omitempty" binding:"max=2000"`
+	SelectedIndex *int          `json:"selected_index,omitempty" binding:"omitempty,min=0"`
+	Rating        *int          `json:"rating,omitempty" binding:"omitempty,min=1,max=5"`
+	MessageVotes  []MessageVote `json:"message_votes,omitempty" binding:"max=20,dive"`
+	EditedText    string        `json:"edited_text,omitempty" binding:"max=5000"`
+	ReasonTags    []string      `json:"reason_tags,omitempty" binding:"max=10,dive,required,max=50"`
+}
+
+// SaveAIResponseFeedback stores feedback on an AI response of the
+// organization, checking message indexes against the stored messages
+func SaveAIResponseFeedback(aiResponses repositories.AIResponseRepository, feedbackRepository repositories.FeedbackRepository, organizationID string, aiResponseID uuid.UUID, input FeedbackInput) (models.AIResponseFeedback, error) {
+	if input.Feedback == "" && input.SelectedIndex == nil && input.Rating == nil &&
+		len(input.MessageVotes) == 0 && input.EditedText == "" && len(input.ReasonTags) == 0 {
+		return models.AIResponseFeedback{}, fmt.Errorf("%w: feedback is empty", ErrInvalidInput)
+	}
+
+	response, err := aiResponses.Get(organizationID, aiResponseID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return models.AIResponseFeedback{}, ErrAIResponseNotFound
+	}
+	if err != nil {
+		return models.AIResponseFeedback{}, err
+	}
+
+	var messages []ChannelMessage
+	if err := response.Messages.Decode(&messages); err != nil {
+		return models.AIResponseFeedback{}, fmt.Errorf("failed to decode messages: %w", err)
+	}
+	if input.SelectedIndex != nil && *input.SelectedIndex >= len(messages) {
+		return models.AIResponseFeedback{}, fmt.Errorf("%w: selected_index %d out of range", ErrInvalidInput, *input.SelectedIndex)
+	}
+	for _, vote := range input.MessageVotes {
+		if vote.Index >= len(messages) {
+			return models.AIResponseFeedback{}, fmt.Errorf("%w: message vote index %d out of range", ErrInvalidInput, vote.Index)
+		}
+	}
+
+	tags := make([]string, len(input.ReasonTags))
+	for i, tag := range input.ReasonTags {
+		tags[i] = strings.ToLower(strings.TrimSpace(tag))
+	}
+
+	feedback := models.AIResponseFeedback{
+		OrganizationID: organizationID,
+		AIResponseID:   aiResponseID,
+		Feedback:       input.Feedback,
+		SelectedIndex:  input.SelectedIndex,
+		Rating:         input.Rating,
+		EditedText:     strings.TrimSpace(input.EditedText),
+	}
+	if len(input.MessageVotes) > 0 {
+		if feedback.MessageVotes, err = models.NewJSONB(input.MessageVotes); err != nil {
+			return models.AIResponseFeedback{}, fmt.Errorf("failed to encode message votes: %w", err)
+		}
+	}
+	if len(tags) > 0 {
+		if feedback.ReasonTags, err = models.NewJSONB(tags); err != nil {
+			return models.AIResponseFeedback{}, fmt.Errorf("failed to encode reason tags: %w", err)
+		}
+	}
+
+	if err := feedbackRepository.Create(&feedback); err != nil {
+		return models.AIResponseFeedback{}, err
+	}
+	return feedback, nil
+}
@@ -0,0 +1,129 @@
+package helpers
+
+import (
+	"fmt"
+	"go-server/config"
+	models "go-server/models"
+	"go-server/repositories"
+	"strings"
+)
+
+// FewShotSettings control how many highly rated past messages are added to
+// prompts as examples
+type FewShotSettings struct {
+	Enabled     bool
+	MaxExamples int
+	MaxTokens   int
+	MinRating   int
+}
+
+// ResolveFewShotSettings returns the env defaults overridden by the
+// organization's few_shot.* settings
+func ResolveFewShotSettings(settingsRepository repositories.SettingsRepository, organizationID string) (FewShotSettings, error) {
+	defaults := config.LoadFewShotConfig()
+	fewShot := FewShotSettings{
+		Enabled:     defaults.Enabled,
+		MaxExamples: defaults.MaxExamples,
+		MaxTokens:   defaults.MaxTokens,
+		MinRating:   defaults.MinRating,
+	}
+	if organizationID == "" {
+		return fewShot, nil
+	}
+
+	settings, err := settingsRepository.Values(organizationID)
+	if err != nil {
+		return fewShot, err
+	}
+	fewShot.Enabled = BoolSetting(settings, SettingFewShotEnabled, fewShot.Enabled)
+	fewShot.MaxExamples = IntSetting(settings, SettingFewShotMaxExamples, fewShot.MaxExamples)
+	fewShot.MaxTokens = IntSetting(settings, SettingFewShotMaxTokens, fewShot.MaxTokens)
+	return fewShot, nil
+}
+
+// estimateTokens approximates the token count of text at four characters
+// per token
+func estimateTokens(text string) int {
+	return len([]rune(text))/4 + 1
+}
+
+// SelectFewShotExamples picks the organization's best rated past messages
+// for the same channel and goal type: the user's edited text when there is
+// one, otherwise the selected or up-voted message. Examples are added while
+// they fit in the token budget.
+func SelectFewShotExamples(feedback repositories.FeedbackRepository, organizationID string, channel MessageChannel, goalType string, fewShot FewShotSettings) ([]string, error) {
+	if !fewShot.Enabled || fewShot.MaxExamples <= 0 || fewShot.MaxTokens <= 0 || organizationID == "" {
+		return nil, nil
+	}
+
+	candidates, err := feedback.Examples(repositories.FeedbackExampleQuery{
+		OrganizationID: organizationID,
+		Channel:        string(channel),
+		GoalType:       goalType,
+		MinRating:      fewShot.MinRating,
+		Limit:          fewShot.MaxExamples * 4,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load few-shot examples: %w", err)
+	}
+
+	maxLength := getChannelConstraints(channel).MaxLength
+	seen := map[string]bool{}
+	examples := []string{}
+	tokens := 0
+	for _, candidate := range candidates {
+		example := strings.Join(strings.Fields(sanitizeInput(endorsedText(candidate))), " ")
+		if runes := []rune(example); maxLength > 0 && len(runes) > maxLength {
+			example = string(runes[:maxLength])
+		}
+		if example == "" || seen[example] {
+			continue
+		}
+
+		cost := estimateTokens(example)
+		if tokens+cost > fewShot.MaxTokens {
+			continue
+		}
+		seen[example] = true
+		tokens += cost
+		examples = append(examples, example)
+		if len(examples) == fewShot.MaxExamples {
+			break
+		}
+	}
+	return examples, nil
+}
+
+// endorsedText returns the message the feedback endorses
+func endorsedText(candidate repositories.FeedbackExample) string {
+	if text := strings.TrimSpace(candidate.EditedText); text != "" {
+		return text
+	}
+
+	var messages []ChannelMessage
+	if candidate.Messages.Decode(&messages) != nil {
+		return ""
+	}
+	if index := candidate.SelectedIndex; index != nil && *index < len(messages) {
+		return messages[*index].MessageText
+	}
+
+	var votes []MessageVote
+	if len(candidate.MessageVotes) > 0 && candidate.MessageVotes.Decode(&votes) == nil {
+		for _, vote := range votes {
+			if vote.Vote == models.FeedbackVoteUp && vote.Index < len(messages) {
+				return messages[vote.Index].MessageText
+			}
+		}
+	}
+	return ""
+}
+
+func formatExamples(examples []string) string {
+	if len(examples) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(`Example Messages:
+	- Note: Past messages this business rated highly; use them for tone and style only
+	- %s`, strings.Join(examples, "\n\t- "))
+}
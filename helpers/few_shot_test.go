@@ -0,0 +1,130 @@
+package helpers
+
+import (
+	models "go-server/models"
+	"go-server/repositories"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// rateResponse stores a response with messages for the organization and
+// rates it
+func rateResponse(t *testing.T, repos repositories.Repositories, organizationID string, channel MessageChannel, goalType string, messages []string, feedback models.AIResponseFeedback) {
+	t.Helper()
+	input := testAiContext()
+	input.Channel = channel
+	input.Goal.Type = goalType
+	result := AIResponse{Input: input, Channel: channel}
+	for _, message := range messages {
+		result.Response.Messages = append(result.Response.Messages, ChannelMessage{MessageText: message})
+	}
+
+	record, err := SaveAIResponse(repos.AIResponses, organizationID, &result)
+	if err != nil {
+		t.Fatalf("failed to save response: %v", err)
+	}
+	feedback.OrganizationID = organizationID
+	feedback.AIResponseID = record.ID
+	if err := repos.Feedback.Create(&feedback); err != nil {
+		t.Fatalf("failed to save feedback: %v", err)
+	}
+}
+
+func rating(value int) *int {
+	return &value
+}
+
+func testFewShotSettings() FewShotSettings {
+	return FewShotSettings{Enabled: true, MaxExamples: 5, MaxTokens: 600, MinRating: 4}
+}
+
+func TestSelectFewShotExamplesFiltersByRatingChannelAndGoal(t *testing.T) {
+	const organizationID = "org-few-shot"
+	repos := repositories.NewMemory()
+	messages := []string{"First message", "Second message"}
+
+	rateResponse(t, repos, organizationID, Email, "sales", messages, models.AIResponseFeedback{Rating: rating(4), EditedText: "Edited  by\nthe user"})
+	rateResponse(t, repos, organizationID, Email, "sales", messages, models.AIResponseFeedback{Rating: rating(5), SelectedIndex: rating(1)})
+	rateResponse(t, repos, organizationID, Email, "sales", messages, models.AIResponseFeedback{Rating: rating(5), SelectedIndex: rating(1)})
+	rateResponse(t, repos, organizationID, Email, "sales", messages, models.AIResponseFeedback{Rating: rating(3), SelectedIndex: rating(0)})
+	rateResponse(t, repos, organizationID, Email, "sales", messages, models.AIResponseFeedback{Rating: rating(5)})
+	rateResponse(t, repos, organizationID, SMS, "sales", []string{"SMS message"}, models.AIResponseFeedback{Rating: rating(5), SelectedIndex: rating(0)})
+	rateResponse(t, repos, organizationID, Email, "recruitment", []string{"Recruiting message"}, models.AIResponseFeedback{Rating: rating(5), SelectedIndex: rating(0)})
+	rateResponse(t, repos, "org-other", Email, "sales", []string{"Other organization"}, models.AIResponseFeedback{Rating: rating(5), SelectedIndex: rating(0)})
+
+	votes, _ := models.NewJSONB([]MessageVote{{Index: 1, Vote: models.FeedbackVoteDown}, {Index: 0, Vote: models.FeedbackVoteUp}})
+	rateResponse(t, repos, organizationID, Email, "sales", []string{"Up-voted message", "Down-voted message"}, models.AIResponseFeedback{Rating: rating(4), MessageVotes: votes})
+
+	examples, err := SelectFewShotExamples(repos.Feedback, organizationID, Email, "sales", testFewShotSettings())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"Second message", "Up-voted message", "Edited by the user"}
+	if !reflect.DeepEqual(examples, want) {
+		t.Errorf("expected %q, got %q", want, examples)
+	}
+
+	fewShot := testFewShotSettings()
+	fewShot.MinRating = 5
+	examples, _ = SelectFewShotExamples(repos.Feedback, organizationID, Email, "sales", fewShot)
+	if !reflect.DeepEqual(examples, []string{"Second message"}) {
+		t.Errorf("expected only 5 star examples, got %q", examples)
+	}
+}
+
+func TestSelectFewShotExamplesRespectsTheTokenBudget(t *testing.T) {
+	const organizationID = "org-few-shot-budget"
+	repos := repositories.NewMemory()
+
+	long := strings.Repeat("long ", 40)
+	short := strings.Repeat("a", 39)
+	for _, message := range []string{short + "1", long, short + "2", short + "3"} {
+		rateResponse(t, repos, organizationID, Email, "sales", []string{message}, models.AIResponseFeedback{Rating: rating(5), SelectedIndex: rating(0)})
+	}
+
+	fewShot := testFewShotSettings()
+	fewShot.MaxTokens = 2 * estimateTokens(short+"1")
+	examples, err := SelectFewShotExamples(repos.Feedback, organizationID, Email, "sales", fewShot)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(examples) != 2 || strings.Contains(strings.Join(examples, ""), "long") {
+		t.Errorf("expected the two short examples that fit the budget, got %q", examples)
+	}
+
+	fewShot.MaxTokens = 600
+	fewShot.MaxExamples = 1
+	if examples, _ = SelectFewShotExamples(repos.Feedback, organizationID, Email, "sales", fewShot); len(examples) != 1 {
+		t.Errorf("expected at most one example, got %q", examples)
+	}
+
+	fewShot.MaxExamples = 5
+	fewShot.MaxTokens = 0
+	if examples, _ = SelectFewShotExamples(repos.Feedback, organizationID, Email, "sales", fewShot); len(examples) != 0 {
+		t.Errorf("expected no examples without a token budget, got %q", examples)
+	}
+}
+
+func TestFewShotExamplesAreOptInPerOrganization(t *testing.T) {
+	t.Setenv("FEW_SHOT_ENABLED", "false")
+	repos := repositories.NewMemory()
+	const optedIn, optedOut = "org-opted-in", "org-opted-out"
+
+	for _, organizationID := range []string{optedIn, optedOut} {
+		rateResponse(t, repos, organizationID, Email, "sales", []string{"Great message"}, models.AIResponseFeedback{Rating: rating(5), SelectedIndex: rating(0)})
+	}
+	if err := repos.Settings.Create(&models.OrganizationSetting{OrganizationID: optedIn, Key: SettingFewShotEnabled, Value: "true"}); err != nil {
+		t.Fatalf("failed to store setting: %v", err)
+	}
+
+	for organizationID, want := range map[string][]string{optedIn: {"Great message"}, optedOut: nil} {
+		options, err := ResolveGenerationOptions(repos, organizationID, testAiContext())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(options.Examples, want) {
+			t.Errorf("%s: expected examples %q, got %q", organizationID, want, options.Examples)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package helpers
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	maxDerivedInterests   = 8
+	maxProfileActivity    = 5
+	maxProfileAboutLength = 1000
+	maxExperienceSummary  = 200
+)
+
+var hashtagPattern = regexp.MustCompile(`#([\p{L}\p{N}_]+)`)
+
+// stopWords are ignored when inferring interests from post and activity titles
+var stopWords = map[string]bool{
+	"about": true, "after": true, "also": true, "been": true, "being": true,
+	"from": true, "have": true, "here": true, "into": true, "just": true,
+	"like": true, "more": true, "most": true, "much": true, "only": true,
+	"other": true, "over": true, "some": true, "such": true, "than": true,
+	"that": true, "their": true, "them": true, "then": true, "there": true,
+	"these": true, "they": true, "this": true, "those": true, "very": true,
+	"what": true, "when": true, "where": true, "which": true, "while": true,
+	"will": true, "with": true, "would": true, "your": true, "yours": true,
+	"were": true, "does": true, "thank": true, "thanks": true, "today": true,
+	"excited": true, "happy": true, "proud": true, "share": true, "great": true,
+}
+
+// LinkedInProfileToCustomerProfile derives the customer profile used in
+// the prompt from a parsed LinkedIn profile
+func LinkedInProfileToCustomerProfile(profile LinkedInProfile) CustomerProfileStruct {
+	customer := CustomerProfileStruct{
+		Name:      strings.TrimSpace(profile.Name),
+		Title:     strings.TrimSpace(profile.CurrentCompany.Title),
+		Company:   strings.TrimSpace(profile.CurrentCompany.Name),
+		Interests: inferInterests(profile),
+	}
+
+	if customer.Title == "" {
+		customer.Title = strings.TrimSpace(profile.Title)
+	}
+	if len(profile.Experience) > 0 {
+		if customer.Company == "" {
+			customer.Company = strings.TrimSpace(profile.Experience[0].Company)
+		}
+		if customer.Title == "" {
+			customer.Title = strings.TrimSpace(profile.Experience[0].Title)
+		}
+	}
+
+	return customer
+}
+
+// LinkedInProfileDetails extracts the background passed to the prompt
+// alongside the customer profile
+func LinkedInProfileDetails(profile LinkedInProfile) *ProfileDetailsStruct {
+	details := &ProfileDetailsStruct{
+		About:          truncate(strings.TrimSpace(profile.About), maxProfileAboutLength),
+		Location:       strings.TrimSpace(profile.Location),
+		RecentActivity: recentActivity(profile),
+	}
+
+	for _, experience := range profile.Experience {
+		summary := strings.TrimSpace(experience.Title)
+		if experience.Company != "" {
+			summary = fmt.Sprintf("%s at %s", summary, strings.TrimSpace(experience.Company))
+		}
+		if experience.Duration != "" {
+			summary = fmt.Sprintf("%s (%s)", summary, strings.TrimSpace(experience.Duration))
+		}
+		if experience.Description != nil && strings.TrimSpace(*experience.Description) != "" {
+			summary = fmt.Sprintf("%s: %s", summary, truncate(strings.TrimSpace(*experience.Description), maxExperienceSummary))
+		}
+		if summary != "" {
+			details.Experience = append(details.Experience, summary)
+		}
+	}
+
+	for _, education := range profile.Education {
+		parts := []string{}
+		for _, part := range []string{education.Degree, education.FieldOfStudy} {
+			if part = strings.TrimSpace(part); part != "" {
+				parts = append(parts, part)
+			}
+		}
+		summary := strings.TrimSpace(education.SchoolName)
+		if len(parts) > 0 {
+			summary = fmt.Sprintf("%s, %s", strings.Join(parts, " in "), summary)
+		}
+		if summary != "" {
+			details.Education = append(details.Education, summary)
+		}
+	}
+
+	return details
+}
+
+// recentActivity lists the latest posts followed by the latest interactions
+func recentActivity(profile LinkedInProfile) []string {
+	var activity []string
+	for _, post := range profile.Posts {
+		if title := strings.TrimSpace(post.Title); title != "" {
+			activity = append(activity, "Posted: "+truncate(title, maxExperienceSummary))
+		}
+	}
+	for _, item := range profile.Activity {
+		title := strings.TrimSpace(item.Title)
+		if title == "" {
+			continue
+		}
+		interaction := strings.TrimSpace(item.Interaction)
+		if interaction == "" {
+			interaction = "Interacted with"
+		}
+		activity = append(activity, fmt.Sprintf("%s: %s", interaction, truncate(title, maxExperienceSummary)))
+	}
+
+	if len(activity) > maxProfileActivity {
+		activity = activity[:maxProfileActivity]
+	}
+	return activity
+}
+
+// inferInterests collects hashtags and recurring keywords from the titles
+// of the customer's posts and activity
+func inferInterests(profile LinkedInProfile) []string {
+	var texts []string
+	for _, post := range profile.Posts {
+		texts = append(texts, post.Title)
+	}
+	for _, item := range profile.Activity {
+		texts = append(texts, item.Title)
+	}
+
+	interests := []string{}
+	seen := map[string]bool{}
+	add := func(interest string) {
+		key := strings.ToLower(interest)
+		if seen[key] || len(interests) >= maxDerivedInterests {
+			return
+		}
+		seen[key] = true
+		interests = append(interests, interest)
+	}
+
+	for _, text := range texts {
+		for _, match := range hashtagPattern.FindAllStringSubmatch(text, -1) {
+			add(match[1])
+		}
+	}
+
+	counts := map[string]int{}
+	for _, text := range texts {
+		text = hashtagPattern.ReplaceAllString(text, "")
+		words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})
+		for _, word := range words {
+			if len(word) >= 4 && !stopWords[word] {
+				counts[word]++
+			}
+		}
+	}
+
+	keywords := make([]string, 0, len(counts))
+	for word, count := range counts {
+		if count >= 2 {
+			keywords = append(keywords, word)
+		}
+	}
+	sort.Slice(keywords, func(i, j int) bool {
+		if counts[keywords[i]] != counts[keywords[j]] {
+			return counts[keywords[i]] > counts[keywords[j]]
+		}
+		return keywords[i] < keywords[j]
+	})
+	for _, keyword := range keywords {
+		add(keyword)
+	}
+
+	return interests
+}
+
+func truncate(value string, max int) string {
+	runes := []rune(value)
+	if len(runes) <= max {
+		return value
+	}
+	return strings.TrimSpace(string(runes[:max])) + "..."
+}
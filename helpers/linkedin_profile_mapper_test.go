@@ -0,0 +1,186 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLinkedInProfileToCustomerProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile LinkedInProfile
+		want    CustomerProfileStruct
+	}{
+		{
+			name: "current company",
+			profile: LinkedInProfile{
+				Name:           " Jane Roe ",
+				Title:          "VP Engineering at Acme",
+				CurrentCompany: CurrentCompany{Name: "Acme", Title: "VP Engineering"},
+				Experience:     []Experience{{Title: "Engineering Manager", Company: "Initech"}},
+			},
+			want: CustomerProfileStruct{Name: "Jane Roe", Title: "VP Engineering", Company: "Acme", Interests: []string{}},
+		},
+		{
+			name: "headline before experience title",
+			profile: LinkedInProfile{
+				Name:       "Sam Lee",
+				Title:      "Founder",
+				Experience: []Experience{{Title: "CEO", Company: "Tiny Co"}},
+			},
+			want: CustomerProfileStruct{Name: "Sam Lee", Title: "Founder", Company: "Tiny Co", Interests: []string{}},
+		},
+		{
+			name: "latest experience",
+			profile: LinkedInProfile{
+				Name:       "Sam Lee",
+				Experience: []Experience{{Title: "CEO", Company: "Tiny Co"}, {Title: "Engineer", Company: "Big Co"}},
+			},
+			want: CustomerProfileStruct{Name: "Sam Lee", Title: "CEO", Company: "Tiny Co", Interests: []string{}},
+		},
+		{
+			name:    "no company",
+			profile: LinkedInProfile{Name: "Sam Lee"},
+			want:    CustomerProfileStruct{Name: "Sam Lee", Interests: []string{}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := LinkedInProfileToCustomerProfile(test.profile); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %+v, got %+v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestInferInterests(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile LinkedInProfile
+		want    []string
+	}{
+		{
+			name: "hashtags first, deduplicated ignoring case",
+			profile: LinkedInProfile{
+				Posts:    []Post{{Title: "Scaling #AI at Acme"}, {Title: "More #ai and #DataMesh"}},
+				Activity: []Activity{{Title: "Why #datamesh works"}},
+			},
+			want: []string{"AI", "DataMesh"},
+		},
+		{
+			name: "recurring keywords by count then name",
+			profile: LinkedInProfile{
+				Posts: []Post{{Title: "Kubernetes platform costs"}, {Title: "Platform teams and kubernetes"}},
+				Activity: []Activity{
+					{Title: "Observability for platform teams"},
+					{Title: "Costs of observability"},
+				},
+			},
+			want: []string{"platform", "costs", "kubernetes", "observability", "teams"},
+		},
+		{
+			name: "stop words, short and one-off words ignored",
+			profile: LinkedInProfile{
+				Posts: []Post{{Title: "Excited to share this with you"}, {Title: "Excited to share that news"}},
+			},
+			want: []string{},
+		},
+		{
+			name: "capped",
+			profile: LinkedInProfile{
+				Posts: []Post{{Title: "#one #two #three #four #five #six #seven #eight #nine"}},
+			},
+			want: []string{"one", "two", "three", "four", "five", "six", "seven", "eight"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := inferInterests(test.profile); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestLinkedInProfileDetailsTruncates(t *testing.T) {
+	description := strings.Repeat("d", maxExperienceSummary+50)
+	profile := LinkedInProfile{
+		About:      strings.Repeat("ä", maxProfileAboutLength+1),
+		Experience: []Experience{{Title: "CTO", Company: "Acme", Duration: "2 years", Description: &description}},
+		Education:  []Education{{SchoolName: "TU Berlin", Degree: "MSc", FieldOfStudy: "Computer Science"}},
+		Posts:      []Post{{Title: "First post"}, {Title: "Second post"}, {Title: " "}},
+		Activity: []Activity{
+			{Interaction: "Liked", Title: "One"},
+			{Title: "Two"},
+			{Interaction: "Shared", Title: "Three"},
+			{Interaction: "Liked", Title: "Four"},
+		},
+	}
+
+	details := LinkedInProfileDetails(profile)
+
+	if want := strings.Repeat("ä", maxProfileAboutLength) + "..."; details.About != want {
+		t.Errorf("expected about to be cut to %d runes, got %d", maxProfileAboutLength, len([]rune(details.About)))
+	}
+	if want := "CTO at Acme (2 years): " + strings.Repeat("d", maxExperienceSummary) + "..."; len(details.Experience) != 1 || details.Experience[0] != want {
+		t.Errorf("expected a truncated experience summary, got %q", details.Experience)
+	}
+	if want := []string{"MSc in Computer Science, TU Berlin"}; !reflect.DeepEqual(details.Education, want) {
+		t.Errorf("expected %q, got %q", want, details.Education)
+	}
+	want := []string{"Posted: First post", "Posted: Second post", "Liked: One", "Interacted with: Two", "Shared: Three"}
+	if !reflect.DeepEqual(details.RecentActivity, want) {
+		t.Errorf("expected %q, got %q", want, details.RecentActivity)
+	}
+}
+
+func TestLinkedInFixturesMapToCustomerProfiles(t *testing.T) {
+	tests := []struct {
+		fixture   string
+		want      CustomerProfileStruct
+		activity  int
+		education int
+	}{
+		{
+			fixture: "full_profile.json",
+			want: CustomerProfileStruct{
+				Name: "Jane Roe", Title: "VP Engineering", Company: "Acme",
+				Interests: []string{"AI", "DataMesh", "hiring", "platform", "platforms", "teams"},
+			},
+			activity:  maxProfileActivity,
+			education: 2,
+		},
+		{
+			fixture:  "sparse_profile.json",
+			want:     CustomerProfileStruct{Name: "Sam Lee", Title: "Founder", Company: "Tiny Co", Interests: []string{}},
+			activity: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("..", "tools", "linkedin-tool", "testdata", test.fixture))
+			if err != nil {
+				t.Fatalf("failed to read fixture: %v", err)
+			}
+			profile, err := ParseLinkedInDataForAI(data)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := LinkedInProfileToCustomerProfile(profile); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %+v, got %+v", test.want, got)
+			}
+			details := LinkedInProfileDetails(profile)
+			if len(details.RecentActivity) != test.activity || len(details.Education) != test.education {
+				t.Errorf("expected %d activities and %d education entries, got %q and %q",
+					test.activity, test.education, details.RecentActivity, details.Education)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package helpers
+
+import (
+	"encoding/json"
+	"regexp"
+)
+
+var messagesKeyPattern = regexp.MustCompile(`"messages"\s*:\s*$`)
+
+// MessageStreamParser extracts ChannelMessages from a partially received
+// GeneratedMessages JSON document. Each message is returned once, as soon
+// as its closing brace has been seen.
+type MessageStreamParser struct {
+	buffer []byte
+	pos    int
+
+	depth       int
+	inString    bool
+	escaped     bool
+	inMessages  bool
+	objectStart int
+}
+
+// Write appends a chunk of model output and returns the messages completed by it.
+func (p *MessageStreamParser) Write(chunk string) []ChannelMessage {
+	p.buffer = append(p.buffer, chunk...)
+
+	var messages []ChannelMessage
+	for ; p.pos < len(p.buffer); p.pos++ {
+		ch := p.buffer[p.pos]
+
+		if p.inString {
+			switch {
+			case p.escaped:
+				p.escaped = false
+			case ch == '\\':
+				p.escaped = true
+			case ch == '"':
+				p.inString = false
+			}
+			continue
+		}
+
+		switch ch {
+		case '"':
+			p.inString = true
+		case '[':
+			if p.depth == 1 && messagesKeyPattern.Match(p.buffer[:p.pos]) {
+				p.inMessages = true
+			}
+			p.depth++
+		case '{':
+			if p.inMessages && p.depth == 2 {
+				p.objectStart = p.pos
+			}
+			p.depth++
+		case '}':
+			p.depth--
+			if p.inMessages && p.depth == 2 {
+				var message ChannelMessage
+				if err := json.Unmarshal(p.buffer[p.objectStart:p.pos+1], &message); err == nil {
+					messages = append(messages, message)
+				}
+			}
+		case ']':
+			p.depth--
+			if p.inMessages && p.depth == 1 {
+				p.inMessages = false
+			}
+		}
+	}
+
+	return messages
+}
@@ -0,0 +1,75 @@
+package helpers
+
+import "testing"
+
+func TestMessageStreamParser(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   []string
+	}{
+		{
+			name:   "whole document",
+			chunks: []string{`{"messages":[{"message":"Hi"},{"message":"Hello"}]}`},
+			want:   []string{"Hi", "Hello"},
+		},
+		{
+			name:   "split inside a string",
+			chunks: []string{`{"messages":[{"message":"Hi Ja`, `ne"},{"mess`, `age":"Bye"}]}`},
+			want:   []string{"Hi Jane", "Bye"},
+		},
+		{
+			name:   "split inside an escape",
+			chunks: []string{`{"messages":[{"message":"say \`, `"hi\" {not a brace}"}]}`},
+			want:   []string{`say "hi" {not a brace}`},
+		},
+		{
+			name:   "escaped backslash before a closing quote",
+			chunks: []string{`{"messages":[{"message":"C:\\`, `"},{"message":"next"}]}`},
+			want:   []string{`C:\`, "next"},
+		},
+		{
+			name:   "nested braces",
+			chunks: []string{`{"messages":[{"message":"Hi","meta":{"tone":{"warm":true}},"tags":[{"a":1}]}`, `,{"message":"Yo"}]}`},
+			want:   []string{"Hi", "Yo"},
+		},
+		{
+			name:   "messages key inside a string value",
+			chunks: []string{`{"note":"\"messages\": [{\"message\":\"fake\"}]","other":{"messages":[{"message":"nested"}]},"messages":[{"message":"real"}]}`},
+			want:   []string{"real"},
+		},
+		{
+			name:   "one byte at a time",
+			chunks: splitBytes(`{"messages": [{"message":"a}b"}, {"message":"c]d"}]}`),
+			want:   []string{"a}b", "c]d"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var parser MessageStreamParser
+			var got []string
+			for _, chunk := range test.chunks {
+				for _, message := range parser.Write(chunk) {
+					got = append(got, message.MessageText)
+				}
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("expected %q, got %q", test.want, got)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Errorf("message %d: expected %q, got %q", i, test.want[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func splitBytes(document string) []string {
+	chunks := make([]string, len(document))
+	for i := range document {
+		chunks[i] = document[i : i+1]
+	}
+	return chunks
+}
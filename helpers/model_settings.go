@@ -0,0 +1,69 @@
+package helpers
+
+import (
+	"go-server/config"
+	"go-server/repositories"
+	"strconv"
+)
+
+// ModelSettings is the model configuration used for an organization's
+// generations: its ai.* settings with the environment as fallback
+type ModelSettings struct {
+	BaseURL     string   `json:"base_url"`
+	Model       string   `json:"model"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	MaxTokens   int64    `json:"max_tokens,omitempty"`
+	Variants    int      `json:"variants"`
+	// Fallbacks are tried in order when the primary model is unavailable
+	Fallbacks []config.ModelEndpoint `json:"fallbacks,omitempty"`
+}
+
+// ResolveModelSettings merges the organization's ai.* settings over the environment defaults
+func ResolveModelSettings(settingsRepository repositories.SettingsRepository, organizationID string) (ModelSettings, error) {
+	aiConfig := config.LoadAIConfig()
+	modelSettings := ModelSettings{
+		BaseURL:     aiConfig.BaseURL,
+		Model:       aiConfig.Model,
+		Temperature: aiConfig.Temperature,
+		MaxTokens:   aiConfig.MaxTokens,
+		Variants:    aiConfig.Variants,
+		Fallbacks:   aiConfig.Fallbacks,
+	}
+	if organizationID == "" {
+		return modelSettings, nil
+	}
+
+	settings, err := settingsRepository.Values(organizationID)
+	if err != nil {
+		return ModelSettings{}, err
+	}
+
+	// Invalid values are rejected when settings are written, so anything
+	// unparseable here is ignored in favour of the defaults. Endpoints the
+	// operator no longer allows are ignored too.
+	if value := settings[SettingAIBaseURL]; value != "" && validateEndpointSetting(value) == nil {
+		modelSettings.BaseURL = value
+	}
+	if value := settings[SettingAIModel]; value != "" {
+		modelSettings.Model = value
+	}
+	if value, err := strconv.ParseFloat(settings[SettingAITemperature], 64); err == nil {
+		modelSettings.Temperature = &value
+	}
+	if value, err := strconv.ParseInt(settings[SettingAIMaxTokens], 10, 64); err == nil && value > 0 {
+		modelSettings.MaxTokens = value
+	}
+	if value := IntSetting(settings, SettingAIVariants, 0); value > 0 {
+		modelSettings.Variants = value
+	}
+	if fallbacks, err := config.ParseModelEndpoints(settings[SettingAIFallbacks]); err == nil && len(fallbacks) > 0 {
+		modelSettings.Fallbacks = nil
+		for _, fallback := range fallbacks {
+			if aiConfig.AllowsBaseURL(fallback.BaseURL) {
+				modelSettings.Fallbacks = append(modelSettings.Fallbacks, fallback)
+			}
+		}
+	}
+
+	return modelSettings, nil
+}
@@ -0,0 +1,208 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-server/config"
+	"go-server/repositories"
+	promptBuilderTool "go-server/tools/prompt-builder-tool"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Organization settings with a typed meaning. Other keys are stored as
+// free-form strings.
+const (
+	SettingAIBaseURL     = "ai.base_url"
+	SettingAIModel       = "ai.model"
+	SettingAITemperature = "ai.temperature"
+	SettingAIMaxTokens   = "ai.max_tokens"
+	SettingAIVariants    = "ai.variants"
+	SettingAIFallbacks   = "ai.fallbacks"
+
+	SettingLinkedInMaxExperience = "linkedin.max_experience"
+	SettingLinkedInMaxEducation  = "linkedin.max_education"
+	SettingLinkedInMaxActivity   = "linkedin.max_activity"
+	SettingLinkedInMaxPosts      = "linkedin.max_posts"
+
+	SettingRateLimitRequestsPerMinute = "rate_limit.requests_per_minute"
+	SettingRateLimitBurst             = "rate_limit.burst"
+	SettingQuotaDailyTokens           = "quota.daily_tokens"
+	SettingQuotaMonthlyTokens         = "quota.monthly_tokens"
+
+	SettingFewShotEnabled     = "few_shot.enabled"
+	SettingFewShotMaxExamples = "few_shot.max_examples"
+	SettingFewShotMaxTokens   = "few_shot.max_tokens"
+
+	SettingLoggingDebug = "logging.debug"
+)
+
+var settingValidators = map[string]func(value string) error{
+	SettingAIBaseURL:     validateEndpointSetting,
+	SettingAIModel:       validateStringSetting(100),
+	SettingAITemperature: validateFloatSetting(0, 2),
+	SettingAIMaxTokens:   validateIntSetting(1, 128000),
+	SettingAIVariants:    validateIntSetting(1, 10),
+	SettingAIFallbacks: func(value string) error {
+		fallbacks, err := config.ParseModelEndpoints(value)
+		if err != nil {
+			return err
+		}
+		aiConfig := config.LoadAIConfig()
+		for _, fallback := range fallbacks {
+			if !aiConfig.AllowsBaseURL(fallback.BaseURL) {
+				return fmt.Errorf("%s is not an allowed model endpoint", fallback.BaseURL)
+			}
+		}
+		return nil
+	},
+
+	SettingLinkedInMaxExperience: validateIntSetting(1, 50),
+	SettingLinkedInMaxEducation:  validateIntSetting(1, 50),
+	SettingLinkedInMaxActivity:   validateIntSetting(1, 50),
+	SettingLinkedInMaxPosts:      validateIntSetting(1, 50),
+
+	SettingRateLimitRequestsPerMinute: validateIntSetting(0, 100000),
+	SettingRateLimitBurst:             validateIntSetting(0, 100000),
+	SettingQuotaDailyTokens:           validateIntSetting(0, math.MaxInt),
+	SettingQuotaMonthlyTokens:         validateIntSetting(0, math.MaxInt),
+
+	SettingFewShotEnabled:     validateBoolSetting,
+	SettingFewShotMaxExamples: validateIntSetting(0, 10),
+	SettingFewShotMaxTokens:   validateIntSetting(0, 4000),
+
+	SettingLoggingDebug: validateBoolSetting,
+
+	BusinessInfoSettingKey: func(value string) error {
+		var info BusinessInfoStruct
+		if err := json.Unmarshal([]byte(value), &info); err != nil {
+			return err
+		}
+		// Generations use the stored value as is, so it must be as complete
+		// as business info sent with a request
+		return binding.Validator.ValidateStruct(info)
+	},
+	PromptTemplateSettingKey: func(value string) error {
+		_, _, err := promptBuilderTool.ParseRef(value)
+		return err
+	},
+}
+
+// adminSettings are limits the operator sets for an organization, and
+// unredacted debug logging, which puts customer data into the operator's
+// logs, so only the admin key may write them
+var adminSettings = map[string]bool{
+	SettingRateLimitRequestsPerMinute: true,
+	SettingRateLimitBurst:             true,
+	SettingQuotaDailyTokens:           true,
+	SettingQuotaMonthlyTokens:         true,
+	SettingLoggingDebug:               true,
+}
+
+// IsAdminSetting reports whether only the admin key may write a setting
+func IsAdminSetting(key string) bool {
+	return adminSettings[key]
+}
+
+// ValidateOrganizationSetting checks the value of a typed setting
+func ValidateOrganizationSetting(key string, value string) error {
+	validate, ok := settingValidators[key]
+	if !ok {
+		return nil
+	}
+	if err := validate(strings.TrimSpace(value)); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	return nil
+}
+
+func validateURLSetting(value string) error {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("must be an http(s) URL")
+	}
+	return nil
+}
+
+// validateEndpointSetting accepts the model endpoints configured by the
+// operator, which are the only ones the server's API key is sent to
+func validateEndpointSetting(value string) error {
+	if err := validateURLSetting(value); err != nil {
+		return err
+	}
+	if !config.LoadAIConfig().AllowsBaseURL(value) {
+		return fmt.Errorf("%s is not an allowed model endpoint", value)
+	}
+	return nil
+}
+
+func validateStringSetting(maxLength int) func(string) error {
+	return func(value string) error {
+		if value == "" || len(value) > maxLength {
+			return fmt.Errorf("must be between 1 and %d characters", maxLength)
+		}
+		return nil
+	}
+}
+
+func validateIntSetting(min, max int) func(string) error {
+	return func(value string) error {
+		number, err := strconv.Atoi(value)
+		if err != nil || number < min || number > max {
+			return fmt.Errorf("must be an integer between %d and %d", min, max)
+		}
+		return nil
+	}
+}
+
+func validateBoolSetting(value string) error {
+	if _, err := strconv.ParseBool(value); err != nil {
+		return fmt.Errorf("must be true or false")
+	}
+	return nil
+}
+
+func validateFloatSetting(min, max float64) func(string) error {
+	return func(value string) error {
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil || number < min || number > max {
+			return fmt.Errorf("must be a number between %g and %g", min, max)
+		}
+		return nil
+	}
+}
+
+// DebugLoggingEnabled reports whether the organization's logging.debug
+// setting asks for debug logs without redaction
+func DebugLoggingEnabled(settingsRepository repositories.SettingsRepository, organizationID string) bool {
+	settings, err := settingsRepository.Values(organizationID)
+	if err != nil {
+		return false
+	}
+	return BoolSetting(settings, SettingLoggingDebug, false)
+}
+
+// BoolSetting returns the boolean value of a setting, or fallback when it
+// is missing or invalid
+func BoolSetting(settings map[string]string, key string, fallback bool) bool {
+	if value, err := strconv.ParseBool(settings[key]); err == nil {
+		return value
+	}
+	return fallback
+}
+
+// IntSetting returns the integer value of a setting, or fallback when it
+// is missing or invalid
+func IntSetting(settings map[string]string, key string, fallback int) int {
+	if value, err := strconv.Atoi(settings[key]); err == nil {
+		return value
+	}
+	return fallback
+}
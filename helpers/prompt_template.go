@@ -0,0 +1,153 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"go-server/repositories"
+	promptBuilderTool "go-server/tools/prompt-builder-tool"
+)
+
+// PromptTemplateSettingKey is the organization setting selecting the
+// default prompt template, as "name" or "name@version"
+const PromptTemplateSettingKey = "prompt_template"
+
+// defaultVariants is the number of messages requested when no model settings are given
+const defaultVariants = 3
+
+// GenerationOptions carries the per-organization choices used to generate a response
+type GenerationOptions struct {
+	Template promptBuilderTool.Template
+	Model    ModelSettings
+	// Examples are highly rated past messages added to the prompt
+	Examples []string
+	// Ledger records every model call made for the generation
+	Ledger UsageLedger
+}
+
+func (o GenerationOptions) variants() int {
+	if o.Model.Variants < 1 {
+		return defaultVariants
+	}
+	return o.Model.Variants
+}
+
+func (o GenerationOptions) template() promptBuilderTool.Template {
+	if o.Template.Body == "" {
+		return promptBuilderTool.DefaultTemplate()
+	}
+	return o.Template
+}
+
+// ResolveGenerationOptions works out the options for generating input on
+// behalf of the organization
+func ResolveGenerationOptions(repos repositories.Repositories, organizationID string, input AiContext) (GenerationOptions, error) {
+	template, err := ResolvePromptTemplate(repos, organizationID, input.PromptTemplate)
+	if err != nil {
+		return GenerationOptions{}, err
+	}
+	modelSettings, err := ResolveModelSettings(repos.Settings, organizationID)
+	if err != nil {
+		return GenerationOptions{}, err
+	}
+
+	fewShot, err := ResolveFewShotSettings(repos.Settings, organizationID)
+	if err != nil {
+		return GenerationOptions{}, err
+	}
+	examples, err := SelectFewShotExamples(repos.Feedback, organizationID, input.Channel, input.Goal.Type, fewShot)
+	if err != nil {
+		return GenerationOptions{}, err
+	}
+
+	return GenerationOptions{
+		Template: template,
+		Model:    modelSettings,
+		Examples: examples,
+		Ledger:   UsageLedger{Repositories: repos, OrganizationID: organizationID},
+	}, nil
+}
+
+// ResolvePromptTemplate picks the template named by ref, falling back to
+// the organization's prompt_template setting and then the built-in default
+func ResolvePromptTemplate(repos repositories.Repositories, organizationID string, ref string) (promptBuilderTool.Template, error) {
+	if ref == "" && organizationID != "" {
+		settings, err := repos.Settings.Values(organizationID)
+		if err != nil {
+			return promptBuilderTool.Template{}, err
+		}
+		ref = settings[PromptTemplateSettingKey]
+	}
+	if ref == "" {
+		return promptBuilderTool.DefaultTemplate(), nil
+	}
+
+	name, version, err := promptBuilderTool.ParseRef(ref)
+	if err != nil {
+		return promptBuilderTool.Template{}, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	return FindPromptTemplate(repos.PromptTemplates, organizationID, name, version)
+}
+
+// FindPromptTemplate looks up a template version (0 for the latest).
+// Organization templates take precedence over global ones, which take
+// precedence over built-in ones.
+func FindPromptTemplate(templates repositories.PromptTemplateRepository, organizationID string, name string, version int) (promptBuilderTool.Template, error) {
+	stored, err := templates.Find(organizationID, name, version)
+	if err == nil {
+		return promptBuilderTool.Template{Name: stored.Name, Version: stored.Version, Body: stored.Body}, nil
+	}
+	if !errors.Is(err, repositories.ErrNotFound) {
+		return promptBuilderTool.Template{}, err
+	}
+
+	if template, ok := promptBuilderTool.BuiltinTemplate(name, version); ok {
+		return template, nil
+	}
+	if version > 0 {
+		return promptBuilderTool.Template{}, fmt.Errorf("%w: prompt template %s@%d not found", ErrInvalidInput, name, version)
+	}
+	return promptBuilderTool.Template{}, fmt.Errorf("%w: prompt template %s not found", ErrInvalidInput, name)
+}
+
+// ValidatePromptTemplate checks that the template parses and renders
+// against a complete sample input
+func ValidatePromptTemplate(template promptBuilderTool.Template) error {
+	sample := AiContext{
+		Channel:           LinkedIn,
+		AdditionalContext: "Met at a conference",
+		BusinessInfo: BusinessInfoStruct{
+			CompanyName:  "Example Co",
+			Industry:     "Software",
+			CoreProducts: []string{"Example Product"},
+			ValueProps:   []string{"Saves time"},
+		},
+		Goal: GoalStruct{
+			Type:        "sales",
+			Description: "Book a demo",
+			Target:      "15-minute call",
+		},
+		CustomerProfile: CustomerProfileStruct{
+			Name:       "Jane Doe",
+			Title:      "CTO",
+			Company:    "Customer Co",
+			Industry:   "Retail",
+			Interests:  []string{"AI"},
+			RecentNews: []string{"Raised a Series A"},
+		},
+		ProfileDetails: &ProfileDetailsStruct{
+			About:          "Engineering leader",
+			Location:       "Berlin",
+			Experience:     []string{"CTO at Customer Co"},
+			Education:      []string{"MSc in Computer Science, TU Berlin"},
+			RecentActivity: []string{"Posted: Scaling AI"},
+		},
+	}
+
+	_, err := BuildPrompt(template, PromptData{
+		AiContext:   sample,
+		Constraints: getChannelConstraints(sample.Channel),
+		Variants:    defaultVariants,
+		Examples:    []string{"Hi Jane, congratulations on the Series A!"},
+	})
+	return err
+}
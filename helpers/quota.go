@@ -0,0 +1,148 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"go-server/config"
+	"go-server/repositories"
+	"strconv"
+	"time"
+)
+
+// ErrQuotaExceeded is returned for generations of an organization that has
+// used up its daily or monthly token budget
+var ErrQuotaExceeded = errors.New("token quota exceeded")
+
+// OrganizationLimits are the request rate and token budgets of an
+// organization. Zero disables a limit.
+type OrganizationLimits struct {
+	RequestsPerMinute int   `json:"requests_per_minute"`
+	Burst             int   `json:"burst"`
+	DailyTokens       int64 `json:"daily_tokens"`
+	MonthlyTokens     int64 `json:"monthly_tokens"`
+}
+
+// QuotaUsage is the token consumption of one quota period
+type QuotaUsage struct {
+	Period    string    `json:"period"`
+	Used      int64     `json:"used"`
+	Limit     int64     `json:"limit"`
+	Remaining int64     `json:"remaining"`
+	Exceeded  bool      `json:"exceeded"`
+	StartsAt  time.Time `json:"starts_at"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
+
+// OrganizationUsage is the consumption of an organization against its limits
+type OrganizationUsage struct {
+	OrganizationID string             `json:"organization_id"`
+	Limits         OrganizationLimits `json:"limits"`
+	Daily          QuotaUsage         `json:"daily"`
+	Monthly        QuotaUsage         `json:"monthly"`
+}
+
+// ResolveOrganizationLimits returns the env limits overridden by the
+// organization's rate_limit.* and quota.* settings
+func ResolveOrganizationLimits(settingsRepository repositories.SettingsRepository, organizationID string) (OrganizationLimits, error) {
+	defaults := config.LoadLimitConfig()
+	limits := OrganizationLimits{
+		RequestsPerMinute: defaults.RequestsPerMinute,
+		Burst:             defaults.Burst,
+		DailyTokens:       defaults.DailyTokens,
+		MonthlyTokens:     defaults.MonthlyTokens,
+	}
+
+	settings, err := settingsRepository.Values(organizationID)
+	if err != nil {
+		return limits, err
+	}
+
+	limits.RequestsPerMinute = IntSetting(settings, SettingRateLimitRequestsPerMinute, limits.RequestsPerMinute)
+	limits.Burst = IntSetting(settings, SettingRateLimitBurst, limits.Burst)
+	limits.DailyTokens = int64Setting(settings, SettingQuotaDailyTokens, limits.DailyTokens)
+	limits.MonthlyTokens = int64Setting(settings, SettingQuotaMonthlyTokens, limits.MonthlyTokens)
+	return limits, nil
+}
+
+func int64Setting(settings map[string]string, key string, fallback int64) int64 {
+	if value, err := strconv.ParseInt(settings[key], 10, 64); err == nil {
+		return value
+	}
+	return fallback
+}
+
+// GetOrganizationUsage returns the organization's token consumption for the
+// current UTC day and month
+func GetOrganizationUsage(repos repositories.Repositories, organizationID string, now time.Time) (OrganizationUsage, error) {
+	limits, err := ResolveOrganizationLimits(repos.Settings, organizationID)
+	if err != nil {
+		return OrganizationUsage{}, err
+	}
+
+	now = now.UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	daily, err := quotaUsage(repos.Usage, organizationID, "daily", limits.DailyTokens, dayStart, dayStart.AddDate(0, 0, 1))
+	if err != nil {
+		return OrganizationUsage{}, err
+	}
+	monthly, err := quotaUsage(repos.Usage, organizationID, "monthly", limits.MonthlyTokens, monthStart, monthStart.AddDate(0, 1, 0))
+	if err != nil {
+		return OrganizationUsage{}, err
+	}
+
+	return OrganizationUsage{
+		OrganizationID: organizationID,
+		Limits:         limits,
+		Daily:          daily,
+		Monthly:        monthly,
+	}, nil
+}
+
+func quotaUsage(usageRepository repositories.UsageRepository, organizationID string, period string, limit int64, start time.Time, end time.Time) (QuotaUsage, error) {
+	usage := QuotaUsage{Period: period, Limit: limit, StartsAt: start, ResetsAt: end}
+
+	used, err := usageRepository.TokensUsedSince(organizationID, start)
+	if err != nil {
+		return usage, err
+	}
+	usage.Used = used
+	if limit > 0 {
+		usage.Remaining = max(limit-used, 0)
+		usage.Exceeded = used >= limit
+	}
+	return usage, nil
+}
+
+// ExceededQuota returns the first exhausted quota period, if any
+func (usage OrganizationUsage) ExceededQuota() (QuotaUsage, bool) {
+	for _, quota := range []QuotaUsage{usage.Daily, usage.Monthly} {
+		if quota.Exceeded {
+			return quota, true
+		}
+	}
+	return QuotaUsage{}, false
+}
+
+// CheckTokenQuota returns the exhausted quota and ErrQuotaExceeded once the
+// organization has used up a token budget. Other errors mean the limits or
+// the usage could not be loaded.
+func CheckTokenQuota(repos repositories.Repositories, organizationID string, now time.Time) (QuotaUsage, error) {
+	limits, err := ResolveOrganizationLimits(repos.Settings, organizationID)
+	if err != nil {
+		return QuotaUsage{}, err
+	}
+	if limits.DailyTokens <= 0 && limits.MonthlyTokens <= 0 {
+		return QuotaUsage{}, nil
+	}
+
+	usage, err := GetOrganizationUsage(repos, organizationID, now)
+	if err != nil {
+		return QuotaUsage{}, err
+	}
+	if quota, exceeded := usage.ExceededQuota(); exceeded {
+		return quota, fmt.Errorf("%w: %d tokens for the %s period", ErrQuotaExceeded, quota.Limit, quota.Period)
+	}
+	return QuotaUsage{}, nil
+}
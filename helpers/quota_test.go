@@ -0,0 +1,55 @@
+package helpers
+
+import (
+	"errors"
+	models "go-server/models"
+	"go-server/repositories"
+	"testing"
+	"time"
+)
+
+func TestCheckTokenQuota(t *testing.T) {
+	t.Setenv("QUOTA_DAILY_TOKENS", "0")
+	t.Setenv("QUOTA_MONTHLY_TOKENS", "0")
+	const organizationID = "org-quota"
+	repos := repositories.NewMemory()
+	now := time.Now()
+
+	useTokens := func(tokens int64) {
+		t.Helper()
+		if _, err := RecordUsage(repos.Usage, models.UsageRecord{OrganizationID: organizationID, PromptTokens: tokens}); err != nil {
+			t.Fatalf("failed to record usage: %v", err)
+		}
+	}
+	setting := func(key string, value string) {
+		t.Helper()
+		if err := repos.Settings.Create(&models.OrganizationSetting{OrganizationID: organizationID, Key: key, Value: value}); err != nil {
+			t.Fatalf("failed to store setting: %v", err)
+		}
+	}
+
+	useTokens(1000)
+	if _, err := CheckTokenQuota(repos, organizationID, now); err != nil {
+		t.Fatalf("expected no quota without limits, got %v", err)
+	}
+
+	setting(SettingQuotaDailyTokens, "1500")
+	setting(SettingQuotaMonthlyTokens, "5000")
+	if _, err := CheckTokenQuota(repos, organizationID, now); err != nil {
+		t.Fatalf("expected tokens to remain, got %v", err)
+	}
+
+	useTokens(500)
+	quota, err := CheckTokenQuota(repos, organizationID, now)
+	if !errors.Is(err, ErrQuotaExceeded) || quota.Period != "daily" || quota.Used != 1500 || quota.Remaining != 0 {
+		t.Fatalf("expected the daily quota to be exhausted, got %+v, %v", quota, err)
+	}
+	dayStart := now.UTC().Truncate(24 * time.Hour)
+	if !quota.ResetsAt.Equal(dayStart.AddDate(0, 0, 1)) {
+		t.Errorf("expected the daily quota to reset at midnight UTC, got %v", quota.ResetsAt)
+	}
+
+	if _, err := CheckTokenQuota(repos, "org-other", now); err != nil {
+		t.Errorf("expected other organizations to keep their own quota, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package helpers
+
+import (
+	"context"
+	"go-server/config"
+	models "go-server/models"
+	"go-server/repositories"
+	"log/slog"
+)
+
+// ComputeCost prices a call with the AI_PRICES table. Unknown models cost
+// nothing.
+func ComputeCost(model string, promptTokens int64, completionTokens int64) float64 {
+	price, ok := config.LoadPricingConfig().Prices[model]
+	if !ok {
+		return 0
+	}
+	return (float64(promptTokens)*price.PromptPerMillion + float64(completionTokens)*price.CompletionPerMillion) / 1e6
+}
+
+// RecordUsage adds an LLM call to the usage ledger, filling in the total
+// tokens and cost
+func RecordUsage(usage repositories.UsageRepository, record models.UsageRecord) (models.UsageRecord, error) {
+	record = priceUsage(record)
+	if err := usage.Create(&record); err != nil {
+		return models.UsageRecord{}, err
+	}
+	return record, nil
+}
+
+// priceUsage fills in the total tokens and cost of a ledger record
+func priceUsage(record models.UsageRecord) models.UsageRecord {
+	if record.TotalTokens == 0 {
+		record.TotalTokens = record.PromptTokens + record.CompletionTokens
+	}
+	record.Cost = ComputeCost(record.ModelName, record.PromptTokens, record.CompletionTokens)
+	return record
+}
+
+// UsageLedger records the model calls made on behalf of an organization.
+// The zero value records nothing.
+type UsageLedger struct {
+	Repositories   repositories.Repositories
+	OrganizationID string
+}
+
+// Record adds one model call to the ledger. The call has already been paid
+// for, so the record is written even when ctx is cancelled, and failures
+// are logged rather than returned.
+func (ledger UsageLedger) Record(ctx context.Context, record models.UsageRecord) {
+	if ledger.Repositories.Usage == nil {
+		return
+	}
+	record.OrganizationID = ledger.OrganizationID
+	usage := ledger.Repositories.WithContext(context.WithoutCancel(ctx)).Usage
+	if _, err := RecordUsage(usage, record); err != nil {
+		slog.ErrorContext(ctx, "Failed to record usage", "operation", record.Operation, "model", record.ModelName, "error", err)
+	}
+}
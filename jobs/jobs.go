@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"fmt"
+	"go-server/config"
+	"go-server/helpers"
+	models "go-server/models"
+	"go-server/repositories"
+	"time"
+)
+
+// Enqueue stores a pending generation job for the organization. The job is
+// picked up by the next idle worker.
+func Enqueue(jobs repositories.JobRepository, organizationID string, input helpers.AiContext) (models.GenerationJob, error) {
+	encoded, err := models.NewJSONB(input)
+	if err != nil {
+		return models.GenerationJob{}, fmt.Errorf("failed to encode input: %w", err)
+	}
+
+	job := models.GenerationJob{
+		OrganizationID: organizationID,
+		Status:         models.JobStatusPending,
+		Input:          encoded,
+		MaxAttempts:    config.LoadJobConfig().MaxAttempts,
+		RunAt:          time.Now(),
+	}
+	if err := jobs.Create(&job); err != nil {
+		return models.GenerationJob{}, err
+	}
+	return job, nil
+}
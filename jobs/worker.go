@@ -0,0 +1,182 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"go-server/config"
+	"go-server/helpers"
+	"go-server/logging"
+	models "go-server/models"
+	"go-server/repositories"
+	aiTool "go-server/tools/ai-tool"
+	"go-server/tracing"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Pool runs the generation jobs of repos.Jobs. Any number of server
+// instances can run a pool against the same database: each job is claimed
+// by one worker at a time.
+type Pool struct {
+	providers *aiTool.Resolver
+	repos     repositories.Repositories
+	config    *config.JobConfig
+	wg        sync.WaitGroup
+}
+
+func NewPool(providers *aiTool.Resolver, repos repositories.Repositories, jobConfig *config.JobConfig) *Pool {
+	return &Pool{providers: providers, repos: repos, config: jobConfig}
+}
+
+// Start launches the workers. They stop when ctx is cancelled.
+func (p *Pool) Start(ctx context.Context) {
+	for i := 0; i < p.config.Workers; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.run(ctx)
+		}()
+	}
+}
+
+// Wait blocks until all workers have stopped.
+func (p *Pool) Wait() {
+	p.wg.Wait()
+}
+
+func (p *Pool) run(ctx context.Context) {
+	ticker := time.NewTicker(p.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the queue before waiting for the next tick
+		for ctx.Err() == nil {
+			now := time.Now()
+			job, err := p.repos.WithContext(ctx).Jobs.Claim(now, now.Add(-p.config.StaleAfter))
+			if err != nil {
+				if !errors.Is(err, repositories.ErrNotFound) {
+					slog.ErrorContext(ctx, "Failed to claim job", "error", err)
+				}
+				break
+			}
+			p.process(ctx, job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Pool) process(ctx context.Context, job models.GenerationJob) {
+	ctx, span := tracing.Start(ctx, "jobs.process",
+		attribute.String("mobilo.job_id", job.ID.String()),
+		attribute.String("mobilo.organization_id", job.OrganizationID),
+		attribute.Int("mobilo.job_attempt", job.Attempts),
+	)
+	defer span.End()
+	repos := p.repos.WithContext(ctx)
+	ctx = logging.NewContext(ctx, "", "", func(organizationID string) bool {
+		return helpers.DebugLoggingEnabled(p.repos.Settings, organizationID)
+	})
+	logging.SetOrganization(ctx, job.OrganizationID)
+
+	var input helpers.AiContext
+	if err := job.Input.Decode(&input); err != nil {
+		p.fail(ctx, &job, err)
+		return
+	}
+
+	options, err := helpers.ResolveGenerationOptions(repos, job.OrganizationID, input)
+	if err != nil {
+		p.failOrRetry(ctx, &job, err)
+		return
+	}
+
+	quota, err := helpers.CheckTokenQuota(repos, job.OrganizationID, time.Now())
+	if errors.Is(err, helpers.ErrQuotaExceeded) {
+		p.postpone(ctx, &job, quota.ResetsAt, err)
+		return
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to check token quota, not enforcing it", "error", err)
+	}
+
+	result, err := helpers.GenerateAIResponse(ctx, p.providers, input, options)
+	if err != nil {
+		tracing.RecordError(span, err)
+		p.failOrRetry(ctx, &job, err)
+		return
+	}
+
+	record, err := helpers.SaveAIResponse(repos.AIResponses, job.OrganizationID, &result)
+	if err != nil {
+		p.failOrRetry(ctx, &job, err)
+		return
+	}
+
+	now := time.Now()
+	job.Status = models.JobStatusSucceeded
+	job.AIResponseID = &record.ID
+	job.CompletedAt = &now
+	job.LockedAt = nil
+	job.LastError = ""
+	p.save(ctx, &job)
+}
+
+// failOrRetry retries the job later unless the input is invalid or it has
+// run out of attempts
+func (p *Pool) failOrRetry(ctx context.Context, job *models.GenerationJob, cause error) {
+	if errors.Is(cause, helpers.ErrInvalidInput) || job.Attempts >= job.MaxAttempts {
+		p.fail(ctx, job, cause)
+		return
+	}
+	p.retry(ctx, job, cause)
+}
+
+func (p *Pool) retry(ctx context.Context, job *models.GenerationJob, cause error) {
+	delay := helpers.Backoff(p.config.RetryBackoff, p.config.MaxBackoff, job.Attempts)
+	slog.WarnContext(ctx, "Job attempt failed, retrying",
+		"job_id", job.ID, "attempt", job.Attempts, "retry_in", delay.String(), "error", cause)
+
+	job.Status = models.JobStatusPending
+	job.RunAt = time.Now().Add(delay)
+	job.LockedAt = nil
+	job.LastError = cause.Error()
+	p.save(ctx, job)
+}
+
+// postpone puts the job back until runAt without counting the attempt,
+// e.g. until its organization's token quota resets
+func (p *Pool) postpone(ctx context.Context, job *models.GenerationJob, runAt time.Time, cause error) {
+	slog.InfoContext(ctx, "Job postponed", "job_id", job.ID, "run_at", runAt, "reason", cause)
+
+	job.Status = models.JobStatusPending
+	job.Attempts--
+	job.RunAt = runAt
+	job.LockedAt = nil
+	job.LastError = cause.Error()
+	p.save(ctx, job)
+}
+
+func (p *Pool) fail(ctx context.Context, job *models.GenerationJob, cause error) {
+	slog.ErrorContext(ctx, "Job failed", "job_id", job.ID, "attempts", job.Attempts, "error", cause)
+
+	now := time.Now()
+	job.Status = models.JobStatusFailed
+	job.CompletedAt = &now
+	job.LockedAt = nil
+	job.LastError = cause.Error()
+	p.save(ctx, job)
+}
+
+func (p *Pool) save(ctx context.Context, job *models.GenerationJob) {
+	if err := p.repos.WithContext(ctx).Jobs.Save(job); err != nil {
+		slog.ErrorContext(ctx, "Failed to update job", "job_id", job.ID, "error", err)
+	}
+}
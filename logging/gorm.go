@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+// gormLog writes GORM's logs through slog. Failed and slow queries are
+// logged with their SQL under KeySQL, so the values bound to them are
+// redacted like any other customer data.
+type gormLog struct {
+	level     gormLogger.LogLevel
+	slowQuery time.Duration
+}
+
+// GormLogger logs failed queries, and queries slower than slowQuery as
+// warnings. Missing records are not errors.
+func GormLogger(slowQuery time.Duration) gormLogger.Interface {
+	return &gormLog{level: gormLogger.Warn, slowQuery: slowQuery}
+}
+
+func (l *gormLog) LogMode(level gormLogger.LogLevel) gormLogger.Interface {
+	return &gormLog{level: level, slowQuery: l.slowQuery}
+}
+
+func (l *gormLog) Info(ctx context.Context, format string, args ...interface{}) {
+	if l.level >= gormLogger.Info {
+		slog.InfoContext(ctx, fmt.Sprintf(format, args...))
+	}
+}
+
+func (l *gormLog) Warn(ctx context.Context, format string, args ...interface{}) {
+	if l.level >= gormLogger.Warn {
+		slog.WarnContext(ctx, fmt.Sprintf(format, args...))
+	}
+}
+
+func (l *gormLog) Error(ctx context.Context, format string, args ...interface{}) {
+	if l.level >= gormLogger.Error {
+		slog.ErrorContext(ctx, fmt.Sprintf(format, args...))
+	}
+}
+
+func (l *gormLog) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= gormLogger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormLogger.Error:
+		sql, rows := fc()
+		slog.ErrorContext(ctx, "Query failed", "error", err, KeySQL, sql, "rows", rows, "duration_ms", elapsed.Milliseconds())
+	case l.slowQuery > 0 && elapsed > l.slowQuery && l.level >= gormLogger.Warn:
+		sql, rows := fc()
+		slog.WarnContext(ctx, "Slow query", KeySQL, sql, "rows", rows, "duration_ms", elapsed.Milliseconds())
+	case l.level >= gormLogger.Info:
+		sql, rows := fc()
+		slog.DebugContext(ctx, "Query", KeySQL, sql, "rows", rows, "duration_ms", elapsed.Milliseconds())
+	}
+}
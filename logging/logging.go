@@ -0,0 +1,222 @@
+// Package logging writes structured JSON logs with slog. Lines logged with
+// the context of a request carry its request ID, route and organization,
+// and customer data is redacted unless debug logging is enabled for the
+// organization.
+package logging
+
+import (
+	"context"
+	"go-server/config"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys whose values are redacted unless debug logging is enabled
+// for the organization
+const (
+	KeyPrompt       = "prompt"
+	KeyCustomerName = "customer_name"
+	KeyAPIKey       = "api_key"
+	KeySQL          = "sql"
+)
+
+const redacted = "[REDACTED]"
+
+var sensitiveKeys = map[string]bool{
+	KeyPrompt:       true,
+	KeyCustomerName: true,
+	KeyAPIKey:       true,
+	KeySQL:          true,
+}
+
+// apiKeyPattern matches organization API keys and bearer credentials
+// wherever they appear in a message or value
+var apiKeyPattern = regexp.MustCompile(`(?i)\bmk_[0-9a-f]+|\bbearer\s+\S+`)
+
+// Setup makes a JSON handler writing to stderr at the configured level the
+// default slog logger. The standard log package writes through it too.
+// Stdout is left to commands writing their output there, such as export.
+func Setup(logConfig *config.LogConfig) {
+	slog.SetDefault(slog.New(NewHandler(os.Stderr, ParseLevel(logConfig.Level))))
+}
+
+// ParseLevel parses debug, info, warn or error, defaulting to info
+func ParseLevel(value string) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(value))); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// handler adds the request fields of the context to every record and
+// redacts sensitive values. Records below level are only written for
+// organizations with debug logging enabled.
+type handler struct {
+	inner slog.Handler
+	level slog.Leveler
+}
+
+// NewHandler returns a JSON handler writing records at level or above to w
+func NewHandler(w io.Writer, level slog.Leveler) slog.Handler {
+	return &handler{
+		inner: slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		level: level,
+	}
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() || debugEnabled(ctx)
+}
+
+func (h *handler) Handle(ctx context.Context, record slog.Record) error {
+	debug := debugEnabled(ctx)
+	out := slog.NewRecord(record.Time, record.Level, redactString(record.Message, debug), record.PC)
+
+	if fields := fieldsFromContext(ctx); fields != nil {
+		if fields.requestID != "" {
+			out.AddAttrs(slog.String("request_id", fields.requestID))
+		}
+		if fields.route != "" {
+			out.AddAttrs(slog.String("route", fields.route))
+		}
+		if organizationID := fields.organization(); organizationID != "" {
+			out.AddAttrs(slog.String("organization_id", organizationID))
+		}
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		out.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()))
+	}
+
+	record.Attrs(func(attr slog.Attr) bool {
+		out.AddAttrs(redactAttr(attr, debug))
+		return true
+	})
+	return h.inner.Handle(ctx, out)
+}
+
+// WithAttrs binds attributes without a context, so they are always
+// redacted
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	bound := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		bound[i] = redactAttr(attr, false)
+	}
+	return &handler{inner: h.inner.WithAttrs(bound), level: h.level}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{inner: h.inner.WithGroup(name), level: h.level}
+}
+
+func redactAttr(attr slog.Attr, debug bool) slog.Attr {
+	value := attr.Value.Resolve()
+	switch {
+	case debug:
+		return slog.Attr{Key: attr.Key, Value: value}
+	case sensitiveKeys[attr.Key]:
+		return slog.String(attr.Key, redacted)
+	case value.Kind() == slog.KindGroup:
+		group := value.Group()
+		redactedGroup := make([]any, len(group))
+		for i, member := range group {
+			redactedGroup[i] = redactAttr(member, debug)
+		}
+		return slog.Group(attr.Key, redactedGroup...)
+	case value.Kind() == slog.KindString:
+		return slog.String(attr.Key, redactString(value.String(), debug))
+	}
+	if err, ok := value.Any().(error); ok {
+		return slog.String(attr.Key, redactString(err.Error(), debug))
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
+
+func redactString(value string, debug bool) string {
+	if debug {
+		return value
+	}
+	return apiKeyPattern.ReplaceAllString(value, redacted)
+}
+
+type contextKey struct{}
+
+// fields are the request fields added to every line logged with a context
+type fields struct {
+	requestID string
+	route     string
+	// debugEnabledFor reports whether an organization has debug logging
+	// enabled; it is called at most once per request
+	debugEnabledFor func(organizationID string) bool
+
+	mu             sync.Mutex
+	organizationID string
+	debug          *bool
+}
+
+func (f *fields) organization() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.organizationID
+}
+
+func fieldsFromContext(ctx context.Context) *fields {
+	if ctx == nil {
+		return nil
+	}
+	f, _ := ctx.Value(contextKey{}).(*fields)
+	return f
+}
+
+// NewContext returns ctx carrying the fields of a request or job.
+// debugEnabled, which may be nil, decides whether the organization set with
+// SetOrganization gets debug logs without redaction.
+func NewContext(ctx context.Context, requestID string, route string, debugEnabled func(organizationID string) bool) context.Context {
+	return context.WithValue(ctx, contextKey{}, &fields{requestID: requestID, route: route, debugEnabledFor: debugEnabled})
+}
+
+// SetOrganization records the organization a request acts for once it is
+// known. It does nothing for contexts without request fields.
+func SetOrganization(ctx context.Context, organizationID string) {
+	f := fieldsFromContext(ctx)
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.organizationID != organizationID {
+		f.organizationID = organizationID
+		f.debug = nil
+	}
+}
+
+// RequestID returns the ID of the request ctx belongs to
+func RequestID(ctx context.Context) string {
+	if f := fieldsFromContext(ctx); f != nil {
+		return f.requestID
+	}
+	return ""
+}
+
+func debugEnabled(ctx context.Context) bool {
+	f := fieldsFromContext(ctx)
+	if f == nil || f.debugEnabledFor == nil {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.organizationID == "" {
+		return false
+	}
+	if f.debug == nil {
+		debug := f.debugEnabledFor(f.organizationID)
+		f.debug = &debug
+	}
+	return *f.debug
+}
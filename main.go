@@ -1,10 +1,18 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"go-server/config"
-	"go-server/models"
+	"go-server/controllers"
+	"go-server/jobs"
+	"go-server/logging"
+	"go-server/metrics"
+	"go-server/migrations"
+	"go-server/repositories"
 	"go-server/routes"
+	aiTool "go-server/tools/ai-tool"
+	"go-server/tracing"
 	"log"
 	"os"
 
@@ -18,6 +26,8 @@ func main() {
 	if err != nil {
 		log.Fatalf("Error loading .env file: %v", err)
 	}
+	logConfig := config.LoadLogConfig()
+	logging.Setup(logConfig)
 	dbConfig := config.LoadDBConfig()
 
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
@@ -27,17 +37,60 @@ func main() {
 		dbConfig.DBName,
 		dbConfig.DBPort)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logging.GormLogger(logConfig.SlowQuery)})
 	if err != nil {
 		log.Fatalf("Failed to connect to the database: %v", err)
 	}
 
-	models.DB = db
+	if err := db.Use(tracing.GormPlugin()); err != nil {
+		log.Fatalf("Failed to set up query tracing: %v", err)
+	}
+	repos := repositories.NewGorm(db, config.LoadSettingsConfig().CacheTTL)
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export":
+			runExport(repos, os.Args[2:])
+			return
+		case "migrate":
+			runMigrate(db, os.Args[2:])
+			return
+		default:
+			log.Fatalf("Unknown command %q, expected export or migrate", os.Args[1])
+		}
+	}
+
+	// Refuse to serve against an outdated schema
+	pending, err := migrations.Pending(db)
+	if err != nil {
+		log.Fatalf("Failed to check database migrations: %v", err)
+	}
+	if len(pending) > 0 {
+		log.Fatalf("Database schema is behind: %d pending migration(s) up to %s, run `%s migrate up`", len(pending), pending[len(pending)-1], os.Args[0])
+	}
 
-	// AutoMigrate all models
-	db.AutoMigrate(&models.OrganizationSetting{}, &models.AIResponse{})
+	shutdownTracing, err := tracing.Setup(context.Background(), config.LoadTracingConfig())
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	providers, err := aiTool.NewResolver(config.LoadAIConfig())
+	if err != nil {
+		log.Fatalf("Failed to create AI provider: %v", err)
+	}
+	jobs.NewPool(providers, repos, config.LoadJobConfig()).Start(context.Background())
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("Failed to access the database pool: %v", err)
+	}
+	metrics.RegisterDB(sqlDB, dbConfig.DBName)
+	metrics.RegisterJobQueue(func(ctx context.Context) (map[string]int64, error) {
+		return repos.WithContext(ctx).Jobs.Depth()
+	})
 
-	r := routes.SetupRouter()
+	r := routes.SetupRouter(controllers.NewServer(repos, providers))
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "3000"
@@ -0,0 +1,145 @@
+// Package metrics exposes Prometheus metrics of the HTTP API, model calls,
+// the job queue and the database pool on /metrics.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "mobilo"
+
+// jobQueueTimeout bounds the job queue depth query of a scrape
+const jobQueueTimeout = 2 * time.Second
+
+// Causes of failed generations
+const (
+	FailureValidation = "validation"
+	FailureProvider   = "provider"
+	FailureParse      = "parse"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route, method and status.",
+	}, []string{"route", "method", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	llmCallDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "llm_call_duration_seconds",
+		Help:      "Latency of model calls by model, channel and outcome.",
+		Buckets:   prometheus.ExponentialBuckets(0.25, 2, 10),
+	}, []string{"model", "channel", "outcome"})
+
+	llmTokens = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "llm_tokens",
+		Help:      "Tokens per model call by model, channel and type (prompt or completion).",
+		Buckets:   prometheus.ExponentialBuckets(16, 2, 12),
+	}, []string{"model", "channel", "type"})
+
+	generationFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "generation_failures_total",
+		Help:      "Failed generations by cause: validation, provider or parse.",
+	}, []string{"cause"})
+
+	jobQueueDepth = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "job_queue_depth"),
+		"Generation jobs waiting or running, by status.",
+		[]string{"status"}, nil,
+	)
+)
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+// Middleware records the count and latency of every request. Requests that
+// match no route are grouped under the "unmatched" route.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		httpRequests.WithLabelValues(route, c.Request.Method, status).Inc()
+		httpRequestDuration.WithLabelValues(route, c.Request.Method, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveLLMCall records a model call. Token counts are only recorded for
+// successful calls.
+func ObserveLLMCall(model string, channel string, duration time.Duration, promptTokens int64, completionTokens int64, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	llmCallDuration.WithLabelValues(model, channel, outcome).Observe(duration.Seconds())
+	if err == nil {
+		llmTokens.WithLabelValues(model, channel, "prompt").Observe(float64(promptTokens))
+		llmTokens.WithLabelValues(model, channel, "completion").Observe(float64(completionTokens))
+	}
+}
+
+// GenerationFailed counts a failed generation
+func GenerationFailed(cause string) {
+	generationFailures.WithLabelValues(cause).Inc()
+}
+
+// RegisterDB exposes the connection pool statistics of db
+func RegisterDB(db *sql.DB, name string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterJobQueue exposes the job queue depth reported by depth on every
+// scrape. depth must give up when its context is done, so that a hanging
+// database cannot hang /metrics.
+func RegisterJobQueue(depth func(ctx context.Context) (map[string]int64, error)) {
+	prometheus.MustRegister(jobQueueCollector{depth: depth, timeout: jobQueueTimeout})
+}
+
+type jobQueueCollector struct {
+	depth   func(ctx context.Context) (map[string]int64, error)
+	timeout time.Duration
+}
+
+func (collector jobQueueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- jobQueueDepth
+}
+
+func (collector jobQueueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collector.timeout)
+	defer cancel()
+
+	depth, err := collector.depth(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(jobQueueDepth, err)
+		return
+	}
+	for status, count := range depth {
+		ch <- prometheus.MustNewConstMetric(jobQueueDepth, prometheus.GaugeValue, float64(count), status)
+	}
+}
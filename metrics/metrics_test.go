@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// collect gathers the metrics of collector, failing the test if it takes
+// longer than wait
+func collect(t *testing.T, collector prometheus.Collector, wait time.Duration) []prometheus.Metric {
+	t.Helper()
+	ch := make(chan prometheus.Metric, 10)
+	done := make(chan struct{})
+	go func() {
+		collector.Collect(ch)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(wait):
+		t.Fatalf("expected the collector to return within %s", wait)
+	}
+	close(ch)
+
+	var metrics []prometheus.Metric
+	for metric := range ch {
+		metrics = append(metrics, metric)
+	}
+	return metrics
+}
+
+func TestJobQueueCollectorReportsDepth(t *testing.T) {
+	collector := jobQueueCollector{timeout: time.Second, depth: func(ctx context.Context) (map[string]int64, error) {
+		return map[string]int64{"pending": 3, "running": 1}, nil
+	}}
+
+	metrics := collect(t, collector, time.Second)
+	if len(metrics) != 2 {
+		t.Fatalf("expected a gauge per status, got %d metrics", len(metrics))
+	}
+	for _, metric := range metrics {
+		var written dto.Metric
+		if err := metric.Write(&written); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if written.GetGauge().GetValue() == 0 {
+			t.Errorf("expected the queue depth, got %v", written.String())
+		}
+	}
+}
+
+func TestJobQueueCollectorGivesUpOnAHangingDatabase(t *testing.T) {
+	collector := jobQueueCollector{timeout: 10 * time.Millisecond, depth: func(ctx context.Context) (map[string]int64, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+
+	metrics := collect(t, collector, time.Second)
+	if len(metrics) != 1 {
+		t.Fatalf("expected a single invalid metric, got %d metrics", len(metrics))
+	}
+	var written dto.Metric
+	if err := metrics[0].Write(&written); err == nil || !strings.Contains(err.Error(), "deadline exceeded") {
+		t.Errorf("expected the timeout to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"errors"
+	"go-server/config"
+	"go-server/helpers"
+	"go-server/logging"
+	"go-server/repositories"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Context keys set by RequireAPIKey
+const (
+	organizationIDKey = "auth.organization_id"
+	adminKey          = "auth.admin"
+	apiKeyIDKey       = "auth.api_key_id"
+)
+
+// apiKeyFromRequest reads the key from "Authorization: Bearer <key>" or the
+// X-API-Key header
+func apiKeyFromRequest(c *gin.Context) string {
+	if authorization := c.GetHeader("Authorization"); authorization != "" {
+		scheme, key, ok := strings.Cut(authorization, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(key)
+		}
+	}
+	return strings.TrimSpace(c.GetHeader("X-API-Key"))
+}
+
+// RequireAPIKey authenticates the caller against the keys in repos and
+// records the organization the key belongs to. The ADMIN_API_KEY, when
+// configured, may act on behalf of any organization.
+func RequireAPIKey(repos repositories.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := apiKeyFromRequest(c)
+		if key == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key required"})
+			return
+		}
+
+		adminAPIKey := config.LoadAuthConfig().AdminAPIKey
+		if adminAPIKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(adminAPIKey)) == 1 {
+			c.Set(adminKey, true)
+			c.Next()
+			return
+		}
+
+		apiKey, err := helpers.AuthenticateAPIKey(repos.WithContext(c.Request.Context()).APIKeys, key)
+		if err != nil {
+			if errors.Is(err, helpers.ErrInvalidAPIKey) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "Failed to authenticate API key", "error", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to authenticate API key"})
+			return
+		}
+
+		c.Set(organizationIDKey, apiKey.OrganizationID)
+		c.Set(apiKeyIDKey, apiKey.ID.String())
+		logging.SetOrganization(c.Request.Context(), apiKey.OrganizationID)
+		c.Next()
+	}
+}
+
+// RequireAdmin rejects callers not using the admin key
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !IsAdmin(c) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin API key required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireOrganizationParam rejects callers whose key does not belong to the
+// organization named by a path parameter
+func RequireOrganizationParam(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !AuthorizeOrganization(c, c.Param(param)) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// IsAdmin reports whether the caller authenticated with the admin key
+func IsAdmin(c *gin.Context) bool {
+	return c.GetBool(adminKey)
+}
+
+// OrganizationID returns the organization of the caller's key. It is empty
+// for the admin key.
+func OrganizationID(c *gin.Context) string {
+	return c.GetString(organizationIDKey)
+}
+
+// CanAccessOrganization reports whether the caller may act on behalf of an
+// organization
+func CanAccessOrganization(c *gin.Context, organizationID string) bool {
+	if IsAdmin(c) {
+		return true
+	}
+	callerID := OrganizationID(c)
+	return callerID != "" && strings.EqualFold(callerID, organizationID)
+}
+
+// AuthorizeOrganization aborts the request with 403 when the caller may not
+// act on behalf of an organization. Otherwise the organization is added to
+// the request's log lines.
+func AuthorizeOrganization(c *gin.Context, organizationID string) bool {
+	if CanAccessOrganization(c, organizationID) {
+		logging.SetOrganization(c.Request.Context(), organizationID)
+		return true
+	}
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key does not grant access to this organization"})
+	return false
+}
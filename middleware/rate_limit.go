@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"go-server/helpers"
+	"go-server/repositories"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tokenBucket allows burst requests at once and refills at rate requests
+// per second
+type tokenBucket struct {
+	rate    float64
+	burst   float64
+	tokens  float64
+	updated time.Time
+}
+
+// take consumes one token, or returns how long to wait for the next one
+func (bucket *tokenBucket) take(now time.Time) (bool, time.Duration) {
+	bucket.tokens = math.Min(bucket.burst, bucket.tokens+now.Sub(bucket.updated).Seconds()*bucket.rate)
+	bucket.updated = now
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - bucket.tokens) / bucket.rate * float64(time.Second))
+}
+
+var buckets = struct {
+	sync.Mutex
+	entries map[string]*tokenBucket
+}{entries: map[string]*tokenBucket{}}
+
+func allowRequest(organizationID string, limits helpers.OrganizationLimits, now time.Time) (bool, time.Duration) {
+	rate := float64(limits.RequestsPerMinute) / 60
+	burst := float64(max(limits.Burst, 1))
+
+	buckets.Lock()
+	defer buckets.Unlock()
+
+	bucket, ok := buckets.entries[organizationID]
+	if !ok || bucket.rate != rate || bucket.burst != burst {
+		// Limits changed: start over with a full bucket
+		bucket = &tokenBucket{rate: rate, burst: burst, tokens: burst, updated: now}
+		buckets.entries[organizationID] = bucket
+	}
+	return bucket.take(now)
+}
+
+// retryAfterSeconds rounds a wait up to whole seconds for Retry-After
+func retryAfterSeconds(wait time.Duration) string {
+	return strconv.Itoa(max(int(math.Ceil(wait.Seconds())), 1))
+}
+
+// RateLimit limits the request rate of the caller's organization with a
+// token bucket of rate_limit.requests_per_minute and rate_limit.burst. The
+// admin key is not limited.
+func RateLimit(repos repositories.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		organizationID := OrganizationID(c)
+		if organizationID == "" {
+			c.Next()
+			return
+		}
+
+		settings := repos.WithContext(c.Request.Context()).Settings
+		limits, err := helpers.ResolveOrganizationLimits(settings, organizationID)
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "Failed to resolve limits, not rate limiting", "error", err)
+			c.Next()
+			return
+		}
+		if limits.RequestsPerMinute <= 0 {
+			c.Next()
+			return
+		}
+
+		if ok, wait := allowRequest(organizationID, limits, time.Now()); !ok {
+			c.Header("Retry-After", retryAfterSeconds(wait))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error": fmt.Sprintf("Rate limit of %d requests per minute exceeded", limits.RequestsPerMinute),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireTokenQuota rejects generation requests once the caller's
+// organization has used up its daily or monthly token budget. Batches and
+// jobs check the quota again before each generation.
+func RequireTokenQuota(repos repositories.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		organizationID := OrganizationID(c)
+		if organizationID == "" {
+			c.Next()
+			return
+		}
+
+		now := time.Now()
+		quota, err := helpers.CheckTokenQuota(repos.WithContext(c.Request.Context()), organizationID, now)
+		if errors.Is(err, helpers.ErrQuotaExceeded) {
+			c.Header("Retry-After", retryAfterSeconds(quota.ResetsAt.Sub(now)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error": fmt.Sprintf("Token quota of %d for the %s period exceeded", quota.Limit, quota.Period),
+				"quota": quota,
+			})
+			return
+		}
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "Failed to check token quota, not enforcing it", "error", err)
+		}
+		c.Next()
+	}
+}
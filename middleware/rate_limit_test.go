@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"go-server/helpers"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketAllowsBurstThenRefills(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	bucket := &tokenBucket{rate: 1, burst: 3, tokens: 3, updated: now}
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := bucket.take(now); !ok {
+			t.Fatalf("expected request %d of the burst to be allowed", i+1)
+		}
+	}
+	ok, wait := bucket.take(now)
+	if ok || wait != time.Second {
+		t.Fatalf("expected the empty bucket to ask for a 1s wait, got %v %v", ok, wait)
+	}
+
+	if ok, _ := bucket.take(now.Add(500 * time.Millisecond)); ok {
+		t.Errorf("expected half a token not to be enough")
+	}
+	if ok, _ := bucket.take(now.Add(time.Second)); !ok {
+		t.Errorf("expected a request once a token has refilled")
+	}
+
+	if ok, _ := bucket.take(now.Add(time.Hour)); !ok || bucket.tokens != 2 {
+		t.Errorf("expected the bucket to refill up to its burst only, got %v tokens", bucket.tokens)
+	}
+}
+
+func TestAllowRequestKeepsABucketPerOrganizationAndLimits(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	limits := helpers.OrganizationLimits{RequestsPerMinute: 60, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := allowRequest("org-rate-a", limits, now); !ok {
+			t.Fatalf("expected request %d to be allowed", i+1)
+		}
+	}
+	if ok, wait := allowRequest("org-rate-a", limits, now); ok || retryAfterSeconds(wait) != "1" {
+		t.Errorf("expected the third request to wait 1s, got %v %v", ok, wait)
+	}
+	if ok, _ := allowRequest("org-rate-b", limits, now); !ok {
+		t.Errorf("expected another organization to have its own bucket")
+	}
+
+	limits.Burst = 5
+	if ok, _ := allowRequest("org-rate-a", limits, now); !ok {
+		t.Errorf("expected changed limits to start over with a full bucket")
+	}
+}
+
+func TestRetryAfterSecondsRoundsUp(t *testing.T) {
+	for wait, want := range map[time.Duration]string{
+		0:                      "1",
+		100 * time.Millisecond: "1",
+		time.Second:            "1",
+		1500 * time.Millisecond: "2",
+		time.Hour:              "3600",
+	} {
+		if got := retryAfterSeconds(wait); got != want {
+			t.Errorf("retryAfterSeconds(%v): expected %s, got %s", wait, want, got)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"go-server/migrations"
+	"log"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// runMigrate implements the migrate subcommand:
+//
+//	go-server migrate up          apply every pending migration
+//	go-server migrate down [n]    revert the latest n migrations (default 1)
+//	go-server migrate status      list migrations and when they were applied
+func runMigrate(db *gorm.DB, args []string) {
+	if len(args) == 0 {
+		log.Fatal("Usage: migrate up | down [n] | status")
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrations.Up(db)
+		for _, migration := range applied {
+			log.Printf("Applied %s", migration)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(applied) == 0 {
+			log.Print("Database schema is up to date")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				log.Fatalf("Invalid number of migrations to revert: %s", args[1])
+			}
+		}
+		reverted, err := migrations.Down(db, steps)
+		for _, migration := range reverted {
+			log.Printf("Reverted %s", migration)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "status":
+		statuses, err := migrations.Statuses(db)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, status := range statuses {
+			if status.AppliedAt == nil {
+				log.Printf("%s pending", status.Migration)
+			} else {
+				log.Printf("%s applied at %s", status.Migration, status.AppliedAt.Format("2006-01-02 15:04:05"))
+			}
+		}
+	default:
+		log.Fatalf("Unknown migrate command %q, expected up, down or status", args[0])
+	}
+}
@@ -0,0 +1,233 @@
+// Package migrations applies the versioned SQL migrations embedded from
+// sql/. Files are named <version>_<name>.up.sql and
+// <version>_<name>.down.sql and applied in version order; applied versions
+// are recorded in the schema_migrations table.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// lockID serializes migrations across processes with a Postgres advisory lock
+const lockID = 7264010923
+
+// Migration is one versioned schema change. Down is empty for migrations
+// that cannot be reverted.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+func (migration Migration) String() string {
+	return fmt.Sprintf("%04d_%s", migration.Version, migration.Name)
+}
+
+// Status is a migration and when it was applied, if it was
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// schemaMigration is a row of the schema_migrations table
+type schemaMigration struct {
+	Version   int `gorm:"primaryKey"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// All returns the embedded migrations in version order
+func All() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		body, err := files.ReadFile("sql/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(body)
+		} else {
+			migration.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %s has no up file", migration)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+func appliedVersions(db *gorm.DB) (map[int]time.Time, error) {
+	if err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint PRIMARY KEY,
+		name text NOT NULL,
+		applied_at timestamptz NOT NULL
+	)`).Error; err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var rows []schemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load schema_migrations: %w", err)
+	}
+	versions := make(map[int]time.Time, len(rows))
+	for _, row := range rows {
+		versions[row.Version] = row.AppliedAt
+	}
+	return versions, nil
+}
+
+// Statuses lists every migration with its applied time
+func Statuses(db *gorm.DB) ([]Status, error) {
+	migrations, err := All()
+	if err != nil {
+		return nil, err
+	}
+	versions, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(migrations))
+	for i, migration := range migrations {
+		statuses[i] = Status{Migration: migration}
+		if appliedAt, ok := versions[migration.Version]; ok {
+			statuses[i].AppliedAt = &appliedAt
+		}
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations that have not been applied
+func Pending(db *gorm.DB) ([]Migration, error) {
+	statuses, err := Statuses(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, status.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration, each in its own transaction, and
+// returns the applied migrations
+func Up(db *gorm.DB) ([]Migration, error) {
+	pending, err := Pending(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range pending {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if skip, err := lock(tx, migration.Version, false); err != nil || skip {
+				return err
+			}
+			if err := tx.Exec(migration.Up).Error; err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("failed to apply migration %s: %w", migration, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down reverts the latest steps applied migrations and returns them
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	statuses, err := Statuses(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(statuses) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := statuses[i].Migration
+		if statuses[i].AppliedAt == nil {
+			continue
+		}
+		if migration.Down == "" {
+			return done, fmt.Errorf("migration %s cannot be reverted: it has no down file", migration)
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if skip, err := lock(tx, migration.Version, true); err != nil || skip {
+				return err
+			}
+			if err := tx.Exec(migration.Down).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&schemaMigration{}, migration.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("failed to revert migration %s: %w", migration, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// lock takes the migration lock for the transaction and reports whether
+// another process already applied (or, when reverting, reverted) the
+// migration meanwhile
+func lock(tx *gorm.DB, version int, reverting bool) (bool, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockID).Error; err != nil {
+		return false, fmt.Errorf("failed to lock schema_migrations: %w", err)
+	}
+	var count int64
+	if err := tx.Model(&schemaMigration{}).Where("version = ?", version).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return (count > 0) == !reverting, nil
+}
@@ -0,0 +1,102 @@
+package migrations
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+// openTestDB connects to the Postgres database in TEST_DATABASE_DSN, using a
+// fresh schema dropped when the test ends
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	config := &gorm.Config{Logger: gormLogger.Discard}
+
+	admin, err := gorm.Open(postgres.Open(dsn), config)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	schema := fmt.Sprintf("migrations_test_%d", time.Now().UnixNano())
+	if err := admin.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`).Error; err != nil {
+		t.Fatalf("failed to create uuid-ossp: %v", err)
+	}
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+	})
+
+	db, err := gorm.Open(postgres.Open(dsn+" search_path="+schema+",public"), config)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	return db
+}
+
+// TestUpAdoptsBaselineSchema migrates the tables AutoMigrate created for
+// the original models, before any later columns were added
+func TestUpAdoptsBaselineSchema(t *testing.T) {
+	db := openTestDB(t)
+
+	baseline := []string{
+		`CREATE TABLE organization_settings (id uuid PRIMARY KEY DEFAULT uuid_generate_v4(), created_at timestamptz, updated_at timestamptz, deleted_at timestamptz, organization_id text, key text, value text)`,
+		`CREATE TABLE ai_responses (id uuid PRIMARY KEY DEFAULT uuid_generate_v4(), created_at timestamptz, updated_at timestamptz, deleted_at timestamptz, organization_id text, query text, response text)`,
+		`CREATE TABLE ai_response_feedbacks (id uuid PRIMARY KEY DEFAULT uuid_generate_v4(), created_at timestamptz, updated_at timestamptz, deleted_at timestamptz, organization_id text, ai_response_id uuid, feedback text)`,
+		`INSERT INTO ai_responses (organization_id, query, response) VALUES ('org', 'Write to Jane', 'Hi Jane, quick demo?')`,
+		`INSERT INTO organization_settings (organization_id, key, value, created_at, updated_at) VALUES ('org', 'ai.model', 'gpt-4o-mini', now() - interval '1 day', now() - interval '1 day')`,
+		`INSERT INTO organization_settings (organization_id, key, value, created_at, updated_at) VALUES ('org', 'ai.model', 'gpt-4o', now(), now())`,
+	}
+	for _, statement := range baseline {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("failed to create baseline schema: %v", err)
+		}
+	}
+
+	applied, err := Up(db)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	all, _ := All()
+	if len(applied) != len(all) {
+		t.Errorf("expected all %d migrations to be applied, got %v", len(all), applied)
+	}
+
+	for table, columns := range map[string][]string{
+		"ai_responses":          {"channel", "input", "messages", "used_tokens", "model_name", "search_vector", "attempts"},
+		"ai_response_feedbacks": {"rating", "selected_index", "message_votes", "edited_text", "reason_tags"},
+	} {
+		for _, column := range columns {
+			if !db.Migrator().HasColumn(table, column) {
+				t.Errorf("expected %s.%s to be added", table, column)
+			}
+		}
+	}
+
+	var matches int64
+	if err := db.Table("ai_responses").Where("search_vector @@ plainto_tsquery('english', ?)", "demo").Count(&matches).Error; err != nil || matches != 1 {
+		t.Errorf("expected the existing response to be searchable, got %d (%v)", matches, err)
+	}
+
+	var models []string
+	if err := db.Table("organization_settings").Where("key = 'ai.model' AND deleted_at IS NULL").Pluck("value", &models).Error; err != nil ||
+		len(models) != 1 || models[0] != "gpt-4o" {
+		t.Errorf("expected only the latest duplicate setting to remain, got %v (%v)", models, err)
+	}
+	if err := db.Exec(`INSERT INTO organization_settings (organization_id, key, value) VALUES ('org', 'ai.model', 'llama3.2')`).Error; err == nil {
+		t.Errorf("expected a second row for a setting to be rejected")
+	}
+
+	if pending, err := Pending(db); err != nil || len(pending) != 0 {
+		t.Errorf("expected nothing pending, got %v (%v)", pending, err)
+	}
+}
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS usage_records;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS prompt_templates;
+DROP TABLE IF EXISTS generation_jobs;
+DROP TABLE IF EXISTS ai_response_feedbacks;
+DROP TABLE IF EXISTS ai_responses;
+DROP TABLE IF EXISTS organization_settings;
@@ -0,0 +1,143 @@
+-- Baseline schema. Statements are idempotent so databases created by the
+-- former AutoMigrate at startup can adopt versioned migrations.
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS organization_settings (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    organization_id text,
+    key text,
+    value text
+);
+CREATE INDEX IF NOT EXISTS idx_organization_settings_deleted_at ON organization_settings (deleted_at);
+
+CREATE TABLE IF NOT EXISTS ai_responses (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    organization_id text,
+    channel text,
+    input jsonb,
+    query text,
+    prompt_template text,
+    prompt_version bigint,
+    response text,
+    messages jsonb,
+    prompt_tokens bigint,
+    completion_tokens bigint,
+    used_tokens bigint,
+    latency_ms bigint,
+    model_name text,
+    search_vector tsvector GENERATED ALWAYS AS (to_tsvector('english', coalesce(query, '') || ' ' || coalesce(response, ''))) STORED
+);
+-- AutoMigrate databases created before these columns only have the
+-- baseline id, timestamps, organization_id, query and response
+ALTER TABLE ai_responses ADD COLUMN IF NOT EXISTS channel text;
+ALTER TABLE ai_responses ADD COLUMN IF NOT EXISTS input jsonb;
+ALTER TABLE ai_responses ADD COLUMN IF NOT EXISTS prompt_template text;
+ALTER TABLE ai_responses ADD COLUMN IF NOT EXISTS prompt_version bigint;
+ALTER TABLE ai_responses ADD COLUMN IF NOT EXISTS messages jsonb;
+ALTER TABLE ai_responses ADD COLUMN IF NOT EXISTS prompt_tokens bigint;
+ALTER TABLE ai_responses ADD COLUMN IF NOT EXISTS completion_tokens bigint;
+ALTER TABLE ai_responses ADD COLUMN IF NOT EXISTS used_tokens bigint;
+ALTER TABLE ai_responses ADD COLUMN IF NOT EXISTS latency_ms bigint;
+ALTER TABLE ai_responses ADD COLUMN IF NOT EXISTS model_name text;
+ALTER TABLE ai_responses ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (to_tsvector('english', coalesce(query, '') || ' ' || coalesce(response, ''))) STORED;
+CREATE INDEX IF NOT EXISTS idx_ai_responses_deleted_at ON ai_responses (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_ai_responses_organization_id ON ai_responses (organization_id);
+CREATE INDEX IF NOT EXISTS idx_ai_response_search ON ai_responses USING gin (search_vector);
+
+CREATE TABLE IF NOT EXISTS ai_response_feedbacks (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    organization_id text,
+    ai_response_id uuid,
+    feedback text,
+    selected_index bigint,
+    rating bigint,
+    message_votes jsonb,
+    edited_text text,
+    reason_tags jsonb
+);
+ALTER TABLE ai_response_feedbacks ADD COLUMN IF NOT EXISTS selected_index bigint;
+ALTER TABLE ai_response_feedbacks ADD COLUMN IF NOT EXISTS rating bigint;
+ALTER TABLE ai_response_feedbacks ADD COLUMN IF NOT EXISTS message_votes jsonb;
+ALTER TABLE ai_response_feedbacks ADD COLUMN IF NOT EXISTS edited_text text;
+ALTER TABLE ai_response_feedbacks ADD COLUMN IF NOT EXISTS reason_tags jsonb;
+CREATE INDEX IF NOT EXISTS idx_ai_response_feedbacks_deleted_at ON ai_response_feedbacks (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_ai_response_feedbacks_organization_id ON ai_response_feedbacks (organization_id);
+CREATE INDEX IF NOT EXISTS idx_ai_response_feedbacks_ai_response_id ON ai_response_feedbacks (ai_response_id);
+
+CREATE TABLE IF NOT EXISTS generation_jobs (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    organization_id text,
+    status text,
+    input jsonb,
+    attempts bigint,
+    max_attempts bigint,
+    run_at timestamptz,
+    locked_at timestamptz,
+    completed_at timestamptz,
+    last_error text,
+    ai_response_id uuid
+);
+CREATE INDEX IF NOT EXISTS idx_generation_jobs_deleted_at ON generation_jobs (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_generation_jobs_organization_id ON generation_jobs (organization_id);
+CREATE INDEX IF NOT EXISTS idx_generation_jobs_status ON generation_jobs (status);
+CREATE INDEX IF NOT EXISTS idx_generation_jobs_run_at ON generation_jobs (run_at);
+
+CREATE TABLE IF NOT EXISTS prompt_templates (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    organization_id text,
+    name text,
+    version bigint,
+    description text,
+    body text
+);
+CREATE INDEX IF NOT EXISTS idx_prompt_templates_deleted_at ON prompt_templates (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_prompt_template_version ON prompt_templates (organization_id, name, version);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    organization_id text,
+    name text,
+    prefix text,
+    key_hash text,
+    last_used_at timestamptz,
+    revoked_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_deleted_at ON api_keys (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_api_keys_organization_id ON api_keys (organization_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys (key_hash);
+
+CREATE TABLE IF NOT EXISTS usage_records (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    organization_id text,
+    ai_response_id uuid,
+    operation text,
+    channel text,
+    model_name text,
+    prompt_tokens bigint,
+    completion_tokens bigint,
+    total_tokens bigint,
+    cost decimal
+);
+CREATE INDEX IF NOT EXISTS idx_usage_records_deleted_at ON usage_records (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_usage_records_organization_id ON usage_records (organization_id);
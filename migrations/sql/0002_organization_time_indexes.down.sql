@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_ai_responses_organization_created;
+DROP INDEX IF EXISTS idx_usage_records_organization_created;
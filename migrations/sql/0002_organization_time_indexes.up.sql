@@ -0,0 +1,3 @@
+-- Quota checks, usage reports and listings filter by organization and time
+CREATE INDEX IF NOT EXISTS idx_usage_records_organization_created ON usage_records (organization_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_ai_responses_organization_created ON ai_responses (organization_id, created_at, id);
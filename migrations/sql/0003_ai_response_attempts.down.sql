@@ -0,0 +1 @@
+ALTER TABLE ai_responses DROP COLUMN IF EXISTS attempts;
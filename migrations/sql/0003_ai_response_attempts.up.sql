@@ -0,0 +1,2 @@
+-- Model calls made for each response, including failed attempts and fallbacks
+ALTER TABLE ai_responses ADD COLUMN IF NOT EXISTS attempts jsonb;
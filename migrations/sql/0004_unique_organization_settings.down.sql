@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_organization_settings_key;
@@ -0,0 +1,14 @@
+-- Keep only the latest row of each organization setting, then allow one per key
+UPDATE organization_settings SET deleted_at = now()
+WHERE id IN (
+    SELECT id FROM (
+        SELECT id, row_number() OVER (
+            PARTITION BY organization_id, key
+            ORDER BY updated_at DESC NULLS LAST, created_at DESC NULLS LAST, id
+        ) AS position
+        FROM organization_settings
+        WHERE deleted_at IS NULL
+    ) ranked
+    WHERE position > 1
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organization_settings_key ON organization_settings (organization_id, key) WHERE deleted_at IS NULL;
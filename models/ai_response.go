@@ -7,13 +7,31 @@ import (
 
 type AIResponse struct {
 	gorm.Model
-	ID             uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
-	OrganizationID string
-	Query          string
-	Response       string
+	ID               uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	OrganizationID   string    `gorm:"index"`
+	Channel          string
+	Input            JSONB `gorm:"type:jsonb"`
+	Query            string
+	PromptTemplate   string
+	PromptVersion    int
+	Response         string
+	Messages         JSONB `gorm:"type:jsonb"`
+	PromptTokens     int64
+	CompletionTokens int64
+	UsedTokens       int64
+	LatencyMs        int64
+	ModelName        string
+	// Attempts lists the model calls made for the response, including
+	// failed ones
+	Attempts JSONB `gorm:"type:jsonb"`
+	// SearchVector indexes Query and Response for full-text search. It is
+	// generated by Postgres and never read or written by the application.
+	SearchVector string `gorm:"->:false;<-:false;type:tsvector GENERATED ALWAYS AS (to_tsvector('english', coalesce(query, '') || ' ' || coalesce(response, ''))) STORED;index:idx_ai_response_search,type:gin" json:"-"`
 }
 
 func (aiResponse *AIResponse) BeforeCreate(tx *gorm.DB) (err error) {
-	aiResponse.ID = uuid.New()
+	if aiResponse.ID == uuid.Nil {
+		aiResponse.ID = uuid.New()
+	}
 	return
 }
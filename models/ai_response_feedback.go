@@ -5,12 +5,26 @@ import (
 	"gorm.io/gorm"
 )
 
+// Votes on a single generated message
+const (
+	FeedbackVoteUp   = "up"
+	FeedbackVoteDown = "down"
+)
+
+// AIResponseFeedback is a user's feedback on the messages of a stored
+// AIResponse. SelectedIndex and the MessageVotes indexes refer to positions
+// in AIResponse.Messages.
 type AIResponseFeedback struct {
 	gorm.Model
 	ID             uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
-	OrganizationID string
-	AIResponseID   uuid.UUID
+	OrganizationID string    `gorm:"index"`
+	AIResponseID   uuid.UUID `gorm:"type:uuid;index"`
 	Feedback       string
+	SelectedIndex  *int
+	Rating         *int
+	MessageVotes   JSONB `gorm:"type:jsonb"`
+	EditedText     string
+	ReasonTags     JSONB `gorm:"type:jsonb"`
 }
 
 func (aiResponseFeedback *AIResponseFeedback) BeforeCreate(tx *gorm.DB) (err error) {
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// APIKey authenticates calls on behalf of one organization. Only the
+// SHA-256 hash of the key is stored; Prefix is kept to identify keys in
+// listings.
+type APIKey struct {
+	gorm.Model
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	OrganizationID string    `gorm:"index"`
+	Name           string
+	Prefix         string
+	KeyHash        string `gorm:"uniqueIndex" json:"-"`
+	LastUsedAt     *time.Time
+	RevokedAt      *time.Time
+}
+
+func (apiKey *APIKey) BeforeCreate(tx *gorm.DB) (err error) {
+	apiKey.ID = uuid.New()
+	return
+}
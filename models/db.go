@@ -1,7 +0,0 @@
-package models
-
-import (
-	"gorm.io/gorm"
-)
-
-var DB *gorm.DB
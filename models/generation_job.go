@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+type GenerationJob struct {
+	gorm.Model
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	OrganizationID string    `gorm:"index"`
+	Status         string    `gorm:"index"`
+	Input          JSONB     `gorm:"type:jsonb"`
+	Attempts       int
+	MaxAttempts    int
+	RunAt          time.Time `gorm:"index"`
+	LockedAt       *time.Time
+	CompletedAt    *time.Time
+	LastError      string
+	AIResponseID   *uuid.UUID `gorm:"type:uuid"`
+}
+
+func (job *GenerationJob) BeforeCreate(tx *gorm.DB) (err error) {
+	job.ID = uuid.New()
+	return
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PromptTemplate is a stored prompt template version. An empty
+// OrganizationID makes the template available to every organization.
+type PromptTemplate struct {
+	gorm.Model
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	OrganizationID string    `gorm:"uniqueIndex:idx_prompt_template_version"`
+	Name           string    `gorm:"uniqueIndex:idx_prompt_template_version"`
+	Version        int       `gorm:"uniqueIndex:idx_prompt_template_version"`
+	Description    string
+	Body           string
+}
+
+func (template *PromptTemplate) BeforeCreate(tx *gorm.DB) (err error) {
+	template.ID = uuid.New()
+	return
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Operations recorded in the usage ledger
+const (
+	UsageOperationGeneration   = "generation"
+	UsageOperationBusinessInfo = "business_info"
+)
+
+// UsageRecord is one LLM call in the usage ledger. Cost is in USD, computed
+// from the price table at the time of the call.
+type UsageRecord struct {
+	gorm.Model
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	OrganizationID string    `gorm:"index"`
+	// AIResponseID is the ID a generation's result is saved under. Calls of
+	// failed generations refer to a response that was never saved.
+	AIResponseID     *uuid.UUID
+	Operation        string
+	Channel          string
+	ModelName        string
+	PromptTokens     int64
+	CompletionTokens int64
+	TotalTokens      int64
+	Cost             float64
+}
+
+func (usageRecord *UsageRecord) BeforeCreate(tx *gorm.DB) (err error) {
+	usageRecord.ID = uuid.New()
+	return
+}
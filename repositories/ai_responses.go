@@ -0,0 +1,256 @@
+package repositories
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	models "go-server/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Sort orders of AI response listings
+const (
+	AIResponseSortNewest    = "newest"
+	AIResponseSortOldest    = "oldest"
+	AIResponseSortTokens    = "tokens"
+	AIResponseSortRelevance = "relevance"
+)
+
+// Page sizes of AI response listings
+const (
+	DefaultAIResponsePageSize = 20
+	MaxAIResponsePageSize     = 100
+)
+
+// searchRank ranks responses against the websearch query bound to ?
+const searchRank = "ts_rank(ai_responses.search_vector, websearch_to_tsquery('english', ?))"
+
+// AIResponseQuery filters and pages an organization's AI responses. Empty
+// fields and zero times are not filtered on.
+type AIResponseQuery struct {
+	OrganizationID  string
+	Channel         string
+	GoalType        string
+	CustomerCompany string
+	From            time.Time
+	To              time.Time
+	MinRating       int
+	MaxRating       int
+	Search          string
+	Sort            string
+	Limit           int
+	Cursor          string
+}
+
+// AIResponsePage is one page of a listing. NextCursor is empty on the last
+// page.
+type AIResponsePage struct {
+	Items      []models.AIResponse `json:"items"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+// aiResponseCursor is the keyset position after the last item of a page
+type aiResponseCursor struct {
+	Sort      string    `json:"s"`
+	CreatedAt time.Time `json:"c,omitempty"`
+	Tokens    int64     `json:"t,omitempty"`
+	Rank      float64   `json:"r,omitempty"`
+	ID        uuid.UUID `json:"i"`
+}
+
+type aiResponseRow struct {
+	models.AIResponse `gorm:"embedded"`
+	SearchRank        float64
+}
+
+func encodeAIResponseCursor(cursor aiResponseCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// cursor decodes the query's cursor and checks it was issued for its sort
+func (query AIResponseQuery) cursor() (aiResponseCursor, error) {
+	var cursor aiResponseCursor
+	data, err := base64.RawURLEncoding.DecodeString(query.Cursor)
+	if err == nil {
+		err = json.Unmarshal(data, &cursor)
+	}
+	if err != nil {
+		return cursor, fmt.Errorf("%w: invalid cursor", ErrInvalidQuery)
+	}
+	if cursor.Sort != query.Sort {
+		return cursor, fmt.Errorf("%w: cursor was issued for sort=%s", ErrInvalidQuery, cursor.Sort)
+	}
+	return cursor, nil
+}
+
+// normalize fills in defaults and validates the query
+func (query *AIResponseQuery) normalize() error {
+	query.Search = strings.TrimSpace(query.Search)
+	if query.Sort == "" {
+		query.Sort = AIResponseSortNewest
+		if query.Search != "" {
+			query.Sort = AIResponseSortRelevance
+		}
+	}
+	switch query.Sort {
+	case AIResponseSortNewest, AIResponseSortOldest, AIResponseSortTokens:
+	case AIResponseSortRelevance:
+		if query.Search == "" {
+			return fmt.Errorf("%w: sort=relevance requires a search query", ErrInvalidQuery)
+		}
+	default:
+		return fmt.Errorf("%w: unknown sort %q", ErrInvalidQuery, query.Sort)
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = DefaultAIResponsePageSize
+	}
+	query.Limit = min(query.Limit, MaxAIResponsePageSize)
+	return nil
+}
+
+type gormAIResponses struct {
+	db *gorm.DB
+}
+
+func (repository *gormAIResponses) withContext(ctx context.Context) AIResponseRepository {
+	return &gormAIResponses{db: repository.db.WithContext(ctx)}
+}
+
+func (repository *gormAIResponses) Create(response *models.AIResponse) error {
+	if err := repository.db.Create(response).Error; err != nil {
+		return fmt.Errorf("failed to save AI response: %w", err)
+	}
+	return nil
+}
+
+func (repository *gormAIResponses) Get(organizationID string, id uuid.UUID) (models.AIResponse, error) {
+	var response models.AIResponse
+	err := repository.db.Where(&models.AIResponse{OrganizationID: organizationID, ID: id}).First(&response).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response, ErrNotFound
+	}
+	if err != nil {
+		return response, fmt.Errorf("failed to load AI response: %w", err)
+	}
+	return response, nil
+}
+
+// List returns one page of an organization's AI responses
+func (repository *gormAIResponses) List(query AIResponseQuery) (AIResponsePage, error) {
+	if err := query.normalize(); err != nil {
+		return AIResponsePage{}, err
+	}
+
+	db := repository.db.Model(&models.AIResponse{}).
+		Where("ai_responses.organization_id = ?", query.OrganizationID)
+	db = query.filter(db)
+
+	if query.Sort == AIResponseSortRelevance {
+		db = db.Select("ai_responses.*, "+searchRank+" AS search_rank", query.Search)
+	} else {
+		db = db.Select("ai_responses.*")
+	}
+
+	if query.Cursor != "" {
+		cursor, err := query.cursor()
+		if err != nil {
+			return AIResponsePage{}, err
+		}
+		db = query.after(db, cursor)
+	}
+
+	var rows []aiResponseRow
+	if err := query.order(db).Limit(query.Limit + 1).Find(&rows).Error; err != nil {
+		return AIResponsePage{}, fmt.Errorf("failed to load AI responses: %w", err)
+	}
+
+	page := AIResponsePage{Items: make([]models.AIResponse, 0, min(len(rows), query.Limit))}
+	for i, row := range rows {
+		if i == query.Limit {
+			last := rows[i-1]
+			page.NextCursor = encodeAIResponseCursor(aiResponseCursor{
+				Sort:      query.Sort,
+				CreatedAt: last.CreatedAt,
+				Tokens:    last.UsedTokens,
+				Rank:      last.SearchRank,
+				ID:        last.ID,
+			})
+			break
+		}
+		page.Items = append(page.Items, row.AIResponse)
+	}
+	return page, nil
+}
+
+func (query AIResponseQuery) filter(db *gorm.DB) *gorm.DB {
+	if query.Channel != "" {
+		db = db.Where("ai_responses.channel = ?", query.Channel)
+	}
+	if query.GoalType != "" {
+		db = db.Where("ai_responses.input->'goal'->>'type' = ?", query.GoalType)
+	}
+	if query.CustomerCompany != "" {
+		db = db.Where("ai_responses.input->'customer_profile'->>'company' ILIKE ?", "%"+escapeLike(query.CustomerCompany)+"%")
+	}
+	if !query.From.IsZero() {
+		db = db.Where("ai_responses.created_at >= ?", query.From)
+	}
+	if !query.To.IsZero() {
+		db = db.Where("ai_responses.created_at < ?", query.To)
+	}
+	if query.MinRating > 0 || query.MaxRating > 0 {
+		ratingQuery := db.Session(&gorm.Session{NewDB: true}).Model(&models.AIResponseFeedback{}).Select("1").
+			Where("ai_response_feedbacks.ai_response_id = ai_responses.id")
+		if query.MinRating > 0 {
+			ratingQuery = ratingQuery.Where("ai_response_feedbacks.rating >= ?", query.MinRating)
+		}
+		if query.MaxRating > 0 {
+			ratingQuery = ratingQuery.Where("ai_response_feedbacks.rating <= ?", query.MaxRating)
+		}
+		db = db.Where("EXISTS (?)", ratingQuery)
+	}
+	if query.Search != "" {
+		db = db.Where("ai_responses.search_vector @@ websearch_to_tsquery('english', ?)", query.Search)
+	}
+	return db
+}
+
+// after continues a listing past the cursor position
+func (query AIResponseQuery) after(db *gorm.DB, cursor aiResponseCursor) *gorm.DB {
+	switch query.Sort {
+	case AIResponseSortOldest:
+		return db.Where("(ai_responses.created_at, ai_responses.id) > (?, ?)", cursor.CreatedAt, cursor.ID)
+	case AIResponseSortTokens:
+		return db.Where("(ai_responses.used_tokens, ai_responses.id) < (?, ?)", cursor.Tokens, cursor.ID)
+	case AIResponseSortRelevance:
+		return db.Where("("+searchRank+", ai_responses.id) < (?, ?)", query.Search, cursor.Rank, cursor.ID)
+	default:
+		return db.Where("(ai_responses.created_at, ai_responses.id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+}
+
+func (query AIResponseQuery) order(db *gorm.DB) *gorm.DB {
+	switch query.Sort {
+	case AIResponseSortOldest:
+		return db.Order("ai_responses.created_at, ai_responses.id")
+	case AIResponseSortTokens:
+		return db.Order("ai_responses.used_tokens DESC, ai_responses.id DESC")
+	case AIResponseSortRelevance:
+		return db.Order("search_rank DESC, ai_responses.id DESC")
+	default:
+		return db.Order("ai_responses.created_at DESC, ai_responses.id DESC")
+	}
+}
+
+// escapeLike escapes the LIKE wildcards in a user supplied value
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
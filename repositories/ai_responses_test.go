@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"go-server/migrations"
+	models "go-server/models"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+const pagingOrganizationID = "org"
+
+// seedPagingResponses stores 25 responses sharing 3 creation times, 4 token
+// counts and 2 search ranks, so every sort has ties broken by ID
+func seedPagingResponses(t *testing.T, repos Repositories, setCreatedAt func(id uuid.UUID, createdAt time.Time)) int {
+	t.Helper()
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	const count = 25
+	for i := 0; i < count; i++ {
+		response := &models.AIResponse{
+			OrganizationID: pagingOrganizationID,
+			Channel:        "email",
+			Query:          "Write to Jane",
+			Response:       strings.Repeat("quick demo ", 1+i%2),
+			UsedTokens:     int64(100 * (i % 4)),
+		}
+		createdAt := base.Add(time.Duration(i%3) * time.Minute)
+		response.CreatedAt = createdAt
+		if err := repos.AIResponses.Create(response); err != nil {
+			t.Fatalf("failed to create response: %v", err)
+		}
+		setCreatedAt(response.ID, createdAt)
+	}
+	if err := repos.AIResponses.Create(&models.AIResponse{OrganizationID: "other", Response: "demo"}); err != nil {
+		t.Fatalf("failed to create response: %v", err)
+	}
+	return count
+}
+
+// testAIResponsePaging pages through every sort and checks each response is
+// listed exactly once, in order
+func testAIResponsePaging(t *testing.T, repos Repositories, count int) {
+	for _, sort := range []string{AIResponseSortNewest, AIResponseSortOldest, AIResponseSortTokens, AIResponseSortRelevance} {
+		t.Run(sort, func(t *testing.T) {
+			query := AIResponseQuery{OrganizationID: pagingOrganizationID, Sort: sort, Limit: 4}
+			if sort == AIResponseSortRelevance {
+				query.Search = "demo"
+			}
+
+			seen := map[uuid.UUID]bool{}
+			var listed []models.AIResponse
+			for pages := 0; ; pages++ {
+				if pages > count {
+					t.Fatalf("expected paging to end, got %d pages", pages)
+				}
+				page, err := repos.AIResponses.List(query)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(page.Items) > query.Limit {
+					t.Fatalf("expected at most %d items, got %d", query.Limit, len(page.Items))
+				}
+				for _, item := range page.Items {
+					if seen[item.ID] {
+						t.Fatalf("expected %s to be listed once, got it again on page %d", item.ID, pages)
+					}
+					seen[item.ID] = true
+				}
+				listed = append(listed, page.Items...)
+				if page.NextCursor == "" {
+					break
+				}
+				query.Cursor = page.NextCursor
+			}
+
+			if len(listed) != count {
+				t.Fatalf("expected %d responses, got %d", count, len(listed))
+			}
+			for i := 1; i < len(listed); i++ {
+				previous, current := listed[i-1], listed[i]
+				var ordered bool
+				switch sort {
+				case AIResponseSortNewest:
+					ordered = !current.CreatedAt.After(previous.CreatedAt)
+				case AIResponseSortOldest:
+					ordered = !current.CreatedAt.Before(previous.CreatedAt)
+				case AIResponseSortTokens:
+					ordered = current.UsedTokens <= previous.UsedTokens
+				default:
+					ordered = true
+				}
+				if !ordered {
+					t.Errorf("expected sort=%s order, got %+v before %+v", sort, previous, current)
+				}
+			}
+		})
+	}
+
+	_, err := repos.AIResponses.List(AIResponseQuery{OrganizationID: pagingOrganizationID, Cursor: "not a cursor"})
+	if !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("expected a malformed cursor to be an invalid query, got %v", err)
+	}
+	page, _ := repos.AIResponses.List(AIResponseQuery{OrganizationID: pagingOrganizationID, Sort: AIResponseSortOldest, Limit: 1})
+	_, err = repos.AIResponses.List(AIResponseQuery{OrganizationID: pagingOrganizationID, Cursor: page.NextCursor})
+	if !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("expected a cursor of another sort to be an invalid query, got %v", err)
+	}
+}
+
+func TestMemoryAIResponsesPaging(t *testing.T) {
+	repos := NewMemory()
+	store := repos.AIResponses.(*memoryAIResponses).store
+	count := seedPagingResponses(t, repos, func(id uuid.UUID, createdAt time.Time) {
+		for i := range store.aiResponses {
+			if store.aiResponses[i].ID == id {
+				store.aiResponses[i].CreatedAt = createdAt
+			}
+		}
+	})
+	testAIResponsePaging(t, repos, count)
+}
+
+// openTestRepositories returns GORM repositories on a migrated, fresh
+// schema of the Postgres database in TEST_DATABASE_DSN, dropped when the
+// test ends
+func openTestRepositories(t *testing.T) Repositories {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	config := &gorm.Config{Logger: gormLogger.Discard}
+
+	admin, err := gorm.Open(postgres.Open(dsn), config)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	schema := fmt.Sprintf("repositories_test_%d", time.Now().UnixNano())
+	if err := admin.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`).Error; err != nil {
+		t.Fatalf("failed to create uuid-ossp: %v", err)
+	}
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+	})
+	db, err := gorm.Open(postgres.Open(dsn+" search_path="+schema+",public"), config)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return NewGorm(db, 0)
+}
+
+func TestGormAIResponsesPaging(t *testing.T) {
+	repos := openTestRepositories(t)
+	count := seedPagingResponses(t, repos, func(uuid.UUID, time.Time) {})
+	testAIResponsePaging(t, repos, count)
+}
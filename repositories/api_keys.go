@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	models "go-server/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type gormAPIKeys struct {
+	db *gorm.DB
+}
+
+func (repository *gormAPIKeys) withContext(ctx context.Context) APIKeyRepository {
+	return &gormAPIKeys{db: repository.db.WithContext(ctx)}
+}
+
+func (repository *gormAPIKeys) Create(apiKey *models.APIKey) error {
+	if err := repository.db.Create(apiKey).Error; err != nil {
+		return fmt.Errorf("failed to save API key: %w", err)
+	}
+	return nil
+}
+
+func (repository *gormAPIKeys) List(organizationID string) ([]models.APIKey, error) {
+	apiKeys := []models.APIKey{}
+	if err := repository.db.Where(&models.APIKey{OrganizationID: organizationID}).
+		Order("created_at").Find(&apiKeys).Error; err != nil {
+		return nil, fmt.Errorf("failed to load API keys: %w", err)
+	}
+	return apiKeys, nil
+}
+
+func (repository *gormAPIKeys) FindActive(keyHash string) (models.APIKey, error) {
+	var apiKey models.APIKey
+	err := repository.db.Where("key_hash = ? AND revoked_at IS NULL", keyHash).First(&apiKey).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apiKey, ErrNotFound
+	}
+	if err != nil {
+		return apiKey, fmt.Errorf("failed to load API key: %w", err)
+	}
+	return apiKey, nil
+}
+
+func (repository *gormAPIKeys) Revoke(organizationID string, id uuid.UUID) (models.APIKey, error) {
+	return revokeAPIKey(repository.db, organizationID, id)
+}
+
+func (repository *gormAPIKeys) Rotate(organizationID string, id uuid.UUID, replacement *models.APIKey) (models.APIKey, error) {
+	var previous models.APIKey
+	err := repository.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if previous, err = revokeAPIKey(tx, organizationID, id); err != nil {
+			return err
+		}
+		replacement.Name = previous.Name
+		if err := tx.Create(replacement).Error; err != nil {
+			return fmt.Errorf("failed to save API key: %w", err)
+		}
+		return nil
+	})
+	return previous, err
+}
+
+func revokeAPIKey(db *gorm.DB, organizationID string, id uuid.UUID) (models.APIKey, error) {
+	var apiKey models.APIKey
+	err := db.Where("organization_id = ? AND id = ? AND revoked_at IS NULL", organizationID, id).First(&apiKey).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apiKey, ErrNotFound
+	}
+	if err != nil {
+		return apiKey, fmt.Errorf("failed to load API key: %w", err)
+	}
+
+	now := time.Now()
+	apiKey.RevokedAt = &now
+	if err := db.Model(&apiKey).Update("revoked_at", now).Error; err != nil {
+		return models.APIKey{}, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	return apiKey, nil
+}
+
+func (repository *gormAPIKeys) Touch(apiKey *models.APIKey, usedAt time.Time) error {
+	if err := repository.db.Model(apiKey).UpdateColumn("last_used_at", usedAt).Error; err != nil {
+		return fmt.Errorf("failed to update API key: %w", err)
+	}
+	return nil
+}
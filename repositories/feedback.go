@@ -0,0 +1,301 @@
+package repositories
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	models "go-server/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type gormFeedback struct {
+	db *gorm.DB
+}
+
+func (repository *gormFeedback) withContext(ctx context.Context) FeedbackRepository {
+	return &gormFeedback{db: repository.db.WithContext(ctx)}
+}
+
+func (repository *gormFeedback) Create(feedback *models.AIResponseFeedback) error {
+	if err := repository.db.Create(feedback).Error; err != nil {
+		return fmt.Errorf("failed to save feedback: %w", err)
+	}
+	return nil
+}
+
+// Page sizes of feedback listings
+const (
+	DefaultFeedbackPageSize = 20
+	MaxFeedbackPageSize     = 100
+)
+
+// FeedbackFilter selects an organization's feedback, optionally on one AI
+// response. Zero times leave the range open.
+type FeedbackFilter struct {
+	OrganizationID string
+	AIResponseID   *uuid.UUID
+	From           time.Time
+	To             time.Time
+}
+
+// FeedbackQuery pages the feedback matching its filter
+type FeedbackQuery struct {
+	FeedbackFilter
+	Limit  int
+	Cursor string
+}
+
+// FeedbackPage is one page of a feedback listing. NextCursor is empty on
+// the last page.
+type FeedbackPage struct {
+	Items      []models.AIResponseFeedback `json:"items"`
+	NextCursor string                      `json:"next_cursor,omitempty"`
+}
+
+// FeedbackSummary aggregates the feedback of an AI response or organization
+type FeedbackSummary struct {
+	Count         int            `json:"count"`
+	RatedCount    int            `json:"rated_count"`
+	AverageRating float64        `json:"average_rating"`
+	Ratings       map[int]int    `json:"ratings"`
+	Selections    map[int]int    `json:"selections"`
+	UpVotes       int            `json:"up_votes"`
+	DownVotes     int            `json:"down_votes"`
+	EditedCount   int            `json:"edited_count"`
+	ReasonTags    map[string]int `json:"reason_tags"`
+}
+
+func newFeedbackSummary() FeedbackSummary {
+	return FeedbackSummary{Ratings: map[int]int{}, Selections: map[int]int{}, ReasonTags: map[string]int{}}
+}
+
+// feedbackCursor is the keyset position after the last item of a page
+type feedbackCursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        uuid.UUID `json:"i"`
+}
+
+func encodeFeedbackCursor(feedback models.AIResponseFeedback) string {
+	data, _ := json.Marshal(feedbackCursor{CreatedAt: feedback.CreatedAt, ID: feedback.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// normalize fills in the default page size and decodes the cursor
+func (query *FeedbackQuery) normalize() (*feedbackCursor, error) {
+	if query.Limit <= 0 {
+		query.Limit = DefaultFeedbackPageSize
+	}
+	query.Limit = min(query.Limit, MaxFeedbackPageSize)
+	if query.Cursor == "" {
+		return nil, nil
+	}
+
+	var cursor feedbackCursor
+	data, err := base64.RawURLEncoding.DecodeString(query.Cursor)
+	if err == nil {
+		err = json.Unmarshal(data, &cursor)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid cursor", ErrInvalidQuery)
+	}
+	return &cursor, nil
+}
+
+// apply adds the conditions of the filter to db
+func (filter FeedbackFilter) apply(db *gorm.DB) *gorm.DB {
+	db = db.Where("ai_response_feedbacks.organization_id = ?", filter.OrganizationID)
+	if filter.AIResponseID != nil {
+		db = db.Where("ai_response_feedbacks.ai_response_id = ?", *filter.AIResponseID)
+	}
+	if !filter.From.IsZero() {
+		db = db.Where("ai_response_feedbacks.created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		db = db.Where("ai_response_feedbacks.created_at < ?", filter.To)
+	}
+	return db
+}
+
+func (repository *gormFeedback) List(query FeedbackQuery) (FeedbackPage, error) {
+	cursor, err := query.normalize()
+	if err != nil {
+		return FeedbackPage{}, err
+	}
+
+	db := query.apply(repository.db.Model(&models.AIResponseFeedback{}))
+	if cursor != nil {
+		db = db.Where("(ai_response_feedbacks.created_at, ai_response_feedbacks.id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+
+	feedback := []models.AIResponseFeedback{}
+	if err := db.Order("ai_response_feedbacks.created_at DESC, ai_response_feedbacks.id DESC").
+		Limit(query.Limit + 1).Find(&feedback).Error; err != nil {
+		return FeedbackPage{}, fmt.Errorf("failed to load feedback: %w", err)
+	}
+	return feedbackPage(feedback, query.Limit), nil
+}
+
+// feedbackPage cuts rows fetched with one extra row down to a page
+func feedbackPage(rows []models.AIResponseFeedback, limit int) FeedbackPage {
+	if len(rows) <= limit {
+		return FeedbackPage{Items: rows}
+	}
+	return FeedbackPage{Items: rows[:limit], NextCursor: encodeFeedbackCursor(rows[limit-1])}
+}
+
+// Summary aggregates in the database, one query per breakdown
+func (repository *gormFeedback) Summary(filter FeedbackFilter) (FeedbackSummary, error) {
+	summary := newFeedbackSummary()
+	feedback := func() *gorm.DB {
+		return filter.apply(repository.db.Model(&models.AIResponseFeedback{}))
+	}
+
+	var totals struct {
+		Count         int
+		RatedCount    int
+		AverageRating float64
+		EditedCount   int
+	}
+	if err := feedback().Select(`COUNT(*) AS count,
+		COUNT(rating) AS rated_count,
+		COALESCE(AVG(rating), 0)::float8 AS average_rating,
+		COUNT(*) FILTER (WHERE edited_text <> '') AS edited_count`).
+		Scan(&totals).Error; err != nil {
+		return FeedbackSummary{}, fmt.Errorf("failed to summarize feedback: %w", err)
+	}
+	summary.Count, summary.RatedCount, summary.AverageRating, summary.EditedCount =
+		totals.Count, totals.RatedCount, totals.AverageRating, totals.EditedCount
+
+	type intCount struct {
+		Value int
+		Count int
+	}
+	for column, counts := range map[string]map[int]int{"rating": summary.Ratings, "selected_index": summary.Selections} {
+		var rows []intCount
+		if err := feedback().Select(column + " AS value, COUNT(*) AS count").
+			Where(column + " IS NOT NULL").Group(column).Scan(&rows).Error; err != nil {
+			return FeedbackSummary{}, fmt.Errorf("failed to summarize feedback: %w", err)
+		}
+		for _, row := range rows {
+			counts[row.Value] = row.Count
+		}
+	}
+
+	type stringCount struct {
+		Value string
+		Count int
+	}
+	var votes []stringCount
+	if err := feedback().Select("votes.vote->>'vote' AS value, COUNT(*) AS count").
+		Joins(`CROSS JOIN LATERAL jsonb_array_elements(CASE WHEN jsonb_typeof(message_votes) = 'array' THEN message_votes ELSE '[]'::jsonb END) AS votes(vote)`).
+		Group("votes.vote->>'vote'").Scan(&votes).Error; err != nil {
+		return FeedbackSummary{}, fmt.Errorf("failed to summarize feedback: %w", err)
+	}
+	for _, vote := range votes {
+		switch vote.Value {
+		case models.FeedbackVoteUp:
+			summary.UpVotes = vote.Count
+		case models.FeedbackVoteDown:
+			summary.DownVotes = vote.Count
+		}
+	}
+
+	var tags []stringCount
+	if err := feedback().Select("tags.tag AS value, COUNT(*) AS count").
+		Joins(`CROSS JOIN LATERAL jsonb_array_elements_text(CASE WHEN jsonb_typeof(reason_tags) = 'array' THEN reason_tags ELSE '[]'::jsonb END) AS tags(tag)`).
+		Group("tags.tag").Scan(&tags).Error; err != nil {
+		return FeedbackSummary{}, fmt.Errorf("failed to summarize feedback: %w", err)
+	}
+	for _, tag := range tags {
+		summary.ReasonTags[tag.Value] = tag.Count
+	}
+	return summary, nil
+}
+
+// FeedbackExampleQuery selects rated feedback on an organization's
+// responses for one channel and goal type
+type FeedbackExampleQuery struct {
+	OrganizationID string
+	Channel        string
+	GoalType       string
+	MinRating      int
+	Limit          int
+}
+
+// FeedbackDatasetQuery selects feedback for a fine-tuning dataset by the
+// responses it rates. Empty fields and zero times are not filtered on.
+type FeedbackDatasetQuery struct {
+	OrganizationID string
+	Channel        string
+	From           time.Time
+	To             time.Time
+}
+
+// FeedbackExample is one feedback entry with the prompt and messages of the
+// response it rates
+type FeedbackExample struct {
+	Query         string
+	Messages      models.JSONB
+	Rating        *int
+	SelectedIndex *int
+	EditedText    string
+	MessageVotes  models.JSONB
+}
+
+// feedbackExamples joins feedback to the responses it rates
+func (repository *gormFeedback) feedbackExamples() *gorm.DB {
+	return repository.db.Table("ai_response_feedbacks AS f").
+		Select("r.query, r.messages, f.rating, f.selected_index, f.edited_text, f.message_votes").
+		Joins("JOIN ai_responses AS r ON r.id = f.ai_response_id AND r.deleted_at IS NULL").
+		Where("f.deleted_at IS NULL")
+}
+
+func (repository *gormFeedback) Examples(query FeedbackExampleQuery) ([]FeedbackExample, error) {
+	examples := []FeedbackExample{}
+	if err := repository.feedbackExamples().
+		Where("f.organization_id = ? AND f.rating >= ?", query.OrganizationID, query.MinRating).
+		Where("r.channel = ? AND r.input->'goal'->>'type' = ?", query.Channel, query.GoalType).
+		Order("f.rating DESC, f.created_at DESC").
+		Limit(query.Limit).
+		Scan(&examples).Error; err != nil {
+		return nil, fmt.Errorf("failed to load feedback examples: %w", err)
+	}
+	return examples, nil
+}
+
+func (repository *gormFeedback) Dataset(query FeedbackDatasetQuery, each func(FeedbackExample) error) error {
+	db := repository.feedbackExamples()
+	if query.OrganizationID != "" {
+		db = db.Where("r.organization_id = ?", query.OrganizationID)
+	}
+	if query.Channel != "" {
+		db = db.Where("r.channel = ?", query.Channel)
+	}
+	if !query.From.IsZero() {
+		db = db.Where("r.created_at >= ?", query.From)
+	}
+	if !query.To.IsZero() {
+		db = db.Where("r.created_at < ?", query.To)
+	}
+
+	rows, err := db.Order("r.created_at, f.created_at").Rows()
+	if err != nil {
+		return fmt.Errorf("failed to load dataset: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var example FeedbackExample
+		if err := repository.db.ScanRows(rows, &example); err != nil {
+			return fmt.Errorf("failed to read dataset row: %w", err)
+		}
+		if err := each(example); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
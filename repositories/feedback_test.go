@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"errors"
+	models "go-server/models"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var feedbackBase = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// seedFeedback stores 7 ratings of one response sharing 3 creation times,
+// so paging has ties broken by ID, and one entry in another organization
+func seedFeedback(t *testing.T, repos Repositories, setCreatedAt func(id uuid.UUID, createdAt time.Time)) uuid.UUID {
+	t.Helper()
+	responseID := uuid.New()
+	create := func(feedback models.AIResponseFeedback) {
+		createdAt := feedback.CreatedAt
+		if err := repos.Feedback.Create(&feedback); err != nil {
+			t.Fatalf("failed to create feedback: %v", err)
+		}
+		setCreatedAt(feedback.ID, createdAt)
+	}
+
+	votes, _ := models.NewJSONB([]map[string]interface{}{{"index": 0, "vote": "up"}, {"index": 1, "vote": "down"}})
+	tags, _ := models.NewJSONB([]string{"tone", "length"})
+	for i := 0; i < 7; i++ {
+		rating, selected := 1+i%5, i%2
+		feedback := models.AIResponseFeedback{OrganizationID: pagingOrganizationID, AIResponseID: responseID, Rating: &rating, SelectedIndex: &selected}
+		feedback.CreatedAt = feedbackBase.Add(time.Duration(i%3) * time.Hour)
+		if i == 0 {
+			feedback.MessageVotes, feedback.ReasonTags, feedback.EditedText = votes, tags, "Hi Jane"
+		}
+		create(feedback)
+	}
+	other := models.AIResponseFeedback{OrganizationID: pagingOrganizationID, AIResponseID: uuid.New(), Feedback: "fine"}
+	other.CreatedAt = feedbackBase
+	create(other)
+	rating := 5
+	foreign := models.AIResponseFeedback{OrganizationID: "other", AIResponseID: responseID, Rating: &rating}
+	foreign.CreatedAt = feedbackBase
+	create(foreign)
+	return responseID
+}
+
+func testFeedbackPaging(t *testing.T, repos Repositories, responseID uuid.UUID) {
+	query := FeedbackQuery{FeedbackFilter: FeedbackFilter{OrganizationID: pagingOrganizationID, AIResponseID: &responseID}, Limit: 3}
+	seen := map[uuid.UUID]bool{}
+	var listed []models.AIResponseFeedback
+	for pages := 0; ; pages++ {
+		if pages > 7 {
+			t.Fatalf("expected paging to end, got %d pages", pages)
+		}
+		page, err := repos.Feedback.List(query)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, item := range page.Items {
+			if seen[item.ID] {
+				t.Fatalf("expected %s to be listed once, got it again on page %d", item.ID, pages)
+			}
+			seen[item.ID] = true
+		}
+		listed = append(listed, page.Items...)
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	if len(listed) != 7 {
+		t.Fatalf("expected 7 entries, got %d", len(listed))
+	}
+	for i := 1; i < len(listed); i++ {
+		if listed[i].CreatedAt.After(listed[i-1].CreatedAt) {
+			t.Errorf("expected newest first, got %s before %s", listed[i-1].CreatedAt, listed[i].CreatedAt)
+		}
+	}
+
+	page, err := repos.Feedback.List(FeedbackQuery{FeedbackFilter: FeedbackFilter{
+		OrganizationID: pagingOrganizationID, From: feedbackBase.Add(time.Hour), To: feedbackBase.Add(2 * time.Hour),
+	}})
+	if err != nil || len(page.Items) != 2 {
+		t.Errorf("expected the 2 entries of the second hour, got %d (%v)", len(page.Items), err)
+	}
+	if page, _ := repos.Feedback.List(FeedbackQuery{FeedbackFilter: FeedbackFilter{OrganizationID: pagingOrganizationID}, Limit: 1000}); len(page.Items) != 8 {
+		t.Errorf("expected the organization's 8 entries, got %d", len(page.Items))
+	}
+
+	_, err = repos.Feedback.List(FeedbackQuery{FeedbackFilter: FeedbackFilter{OrganizationID: pagingOrganizationID}, Cursor: "not a cursor"})
+	if !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("expected a malformed cursor to be an invalid query, got %v", err)
+	}
+}
+
+func testFeedbackSummary(t *testing.T, repos Repositories, responseID uuid.UUID) {
+	summary, err := repos.Feedback.Summary(FeedbackFilter{OrganizationID: pagingOrganizationID, AIResponseID: &responseID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Ratings 1, 2, 3, 4, 5, 1, 2 and selections 0, 1, 0, 1, 0, 1, 0
+	if summary.Count != 7 || summary.RatedCount != 7 || math.Abs(summary.AverageRating-18.0/7) > 1e-9 {
+		t.Errorf("expected 7 ratings averaging 18/7, got %+v", summary)
+	}
+	if summary.Ratings[1] != 2 || summary.Ratings[5] != 1 || summary.Selections[0] != 4 || summary.Selections[1] != 3 {
+		t.Errorf("unexpected breakdowns: ratings %v, selections %v", summary.Ratings, summary.Selections)
+	}
+	if summary.UpVotes != 1 || summary.DownVotes != 1 || summary.EditedCount != 1 || summary.ReasonTags["tone"] != 1 || summary.ReasonTags["length"] != 1 {
+		t.Errorf("unexpected votes, edits or tags: %+v", summary)
+	}
+
+	summary, err = repos.Feedback.Summary(FeedbackFilter{OrganizationID: pagingOrganizationID, From: feedbackBase.Add(time.Hour)})
+	if err != nil || summary.Count != 4 || summary.UpVotes != 0 {
+		t.Errorf("expected the 4 entries after the first hour, got %+v (%v)", summary, err)
+	}
+	summary, err = repos.Feedback.Summary(FeedbackFilter{OrganizationID: "missing"})
+	if err != nil || summary.Count != 0 || summary.AverageRating != 0 || summary.Ratings == nil {
+		t.Errorf("expected an empty summary, got %+v (%v)", summary, err)
+	}
+}
+
+func TestMemoryFeedback(t *testing.T) {
+	repos := NewMemory()
+	store := repos.Feedback.(*memoryFeedback).store
+	responseID := seedFeedback(t, repos, func(id uuid.UUID, createdAt time.Time) {
+		for i := range store.feedback {
+			if store.feedback[i].ID == id {
+				store.feedback[i].CreatedAt = createdAt
+			}
+		}
+	})
+	testFeedbackPaging(t, repos, responseID)
+	testFeedbackSummary(t, repos, responseID)
+}
+
+func TestGormFeedback(t *testing.T) {
+	repos := openTestRepositories(t)
+	responseID := seedFeedback(t, repos, func(uuid.UUID, time.Time) {})
+	testFeedbackPaging(t, repos, responseID)
+	testFeedbackSummary(t, repos, responseID)
+}
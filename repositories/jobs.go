@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	models "go-server/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormJobs struct {
+	db *gorm.DB
+}
+
+func (repository *gormJobs) withContext(ctx context.Context) JobRepository {
+	return &gormJobs{db: repository.db.WithContext(ctx)}
+}
+
+func (repository *gormJobs) Create(job *models.GenerationJob) error {
+	if err := repository.db.Create(job).Error; err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return nil
+}
+
+func (repository *gormJobs) Get(id uuid.UUID) (models.GenerationJob, error) {
+	var job models.GenerationJob
+	err := repository.db.Where(&models.GenerationJob{ID: id}).First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return job, ErrNotFound
+	}
+	if err != nil {
+		return job, fmt.Errorf("failed to load job: %w", err)
+	}
+	return job, nil
+}
+
+// Claim locks the job with SELECT ... FOR UPDATE SKIP LOCKED, so any number
+// of workers can claim jobs from the same database
+func (repository *gormJobs) Claim(now time.Time, staleBefore time.Time) (models.GenerationJob, error) {
+	var job models.GenerationJob
+	err := repository.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_at < ?)",
+				models.JobStatusPending, now,
+				models.JobStatusRunning, staleBefore).
+			Order("run_at").
+			Limit(1).
+			Find(&job)
+		if result.Error != nil {
+			return fmt.Errorf("failed to claim job: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+
+		claim(&job, now)
+		if err := tx.Save(&job).Error; err != nil {
+			return fmt.Errorf("failed to claim job: %w", err)
+		}
+		return nil
+	})
+	return job, err
+}
+
+// claim marks a job as running for another attempt
+func claim(job *models.GenerationJob, now time.Time) {
+	job.Status = models.JobStatusRunning
+	job.LockedAt = &now
+	job.Attempts++
+}
+
+func (repository *gormJobs) Save(job *models.GenerationJob) error {
+	if err := repository.db.Save(job).Error; err != nil {
+		return fmt.Errorf("failed to update job: %w", err)
+	}
+	return nil
+}
+
+func (repository *gormJobs) Depth() (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := repository.db.Model(&models.GenerationJob{}).
+		Select("status, count(*) AS count").
+		Where("status IN ?", []string{models.JobStatusPending, models.JobStatusRunning}).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count jobs: %w", err)
+	}
+
+	depth := map[string]int64{models.JobStatusPending: 0, models.JobStatusRunning: 0}
+	for _, row := range rows {
+		depth[row.Status] = row.Count
+	}
+	return depth, nil
+}
@@ -0,0 +1,798 @@
+package repositories
+
+import (
+	"bytes"
+	models "go-server/models"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memoryStore holds the records of the in-memory repositories
+type memoryStore struct {
+	mu              sync.Mutex
+	settings        []models.OrganizationSetting
+	aiResponses     []models.AIResponse
+	usage           []models.UsageRecord
+	feedback        []models.AIResponseFeedback
+	promptTemplates []models.PromptTemplate
+	apiKeys         []models.APIKey
+	jobs            []models.GenerationJob
+}
+
+// NewMemory returns empty repositories that keep their records in memory.
+// They behave like the GORM ones except that full-text search is
+// approximated by case-insensitive matching of every search term.
+func NewMemory() Repositories {
+	store := &memoryStore{}
+	return Repositories{
+		Settings:        &memorySettings{store: store},
+		AIResponses:     &memoryAIResponses{store: store},
+		Feedback:        &memoryFeedback{store: store},
+		PromptTemplates: &memoryPromptTemplates{store: store},
+		APIKeys:         &memoryAPIKeys{store: store},
+		Usage:           &memoryUsage{store: store},
+		Jobs:            &memoryJobs{store: store},
+	}
+}
+
+type memorySettings struct {
+	store *memoryStore
+}
+
+func (repository *memorySettings) Values(organizationID string) (map[string]string, error) {
+	settings, err := repository.List(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	return settingValues(settings), nil
+}
+
+func (repository *memorySettings) List(organizationID string) ([]models.OrganizationSetting, error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	settings := []models.OrganizationSetting{}
+	for _, setting := range repository.store.settings {
+		if setting.OrganizationID == organizationID {
+			settings = append(settings, setting)
+		}
+	}
+	return settings, nil
+}
+
+func (repository *memorySettings) Get(organizationID string, key string) (models.OrganizationSetting, error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for _, setting := range repository.store.settings {
+		if setting.OrganizationID == organizationID && setting.Key == key {
+			return setting, nil
+		}
+	}
+	return models.OrganizationSetting{}, ErrNotFound
+}
+
+func (repository *memorySettings) Create(setting *models.OrganizationSetting) error {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	now := time.Now()
+	for i, stored := range repository.store.settings {
+		if stored.OrganizationID == setting.OrganizationID && stored.Key == setting.Key {
+			stored.Value = setting.Value
+			stored.UpdatedAt = now
+			repository.store.settings[i] = stored
+			*setting = stored
+			return nil
+		}
+	}
+
+	setting.ID = uuid.New()
+	setting.CreatedAt = now
+	setting.UpdatedAt = now
+	repository.store.settings = append(repository.store.settings, *setting)
+	return nil
+}
+
+func (repository *memorySettings) Update(setting *models.OrganizationSetting) error {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for i, stored := range repository.store.settings {
+		if stored.ID == setting.ID {
+			setting.UpdatedAt = time.Now()
+			repository.store.settings[i] = *setting
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+type memoryAIResponses struct {
+	store *memoryStore
+}
+
+func (repository *memoryAIResponses) Create(response *models.AIResponse) error {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	if response.ID == uuid.Nil {
+		response.ID = uuid.New()
+	}
+	response.CreatedAt = time.Now()
+	response.UpdatedAt = response.CreatedAt
+	repository.store.aiResponses = append(repository.store.aiResponses, *response)
+	return nil
+}
+
+func (repository *memoryAIResponses) Get(organizationID string, id uuid.UUID) (models.AIResponse, error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for _, response := range repository.store.aiResponses {
+		if response.OrganizationID == organizationID && response.ID == id {
+			return response, nil
+		}
+	}
+	return models.AIResponse{}, ErrNotFound
+}
+
+// rankedResponse is a response with its search rank
+type rankedResponse struct {
+	models.AIResponse
+	rank float64
+}
+
+func (repository *memoryAIResponses) List(query AIResponseQuery) (AIResponsePage, error) {
+	if err := query.normalize(); err != nil {
+		return AIResponsePage{}, err
+	}
+	var after *rankedResponse
+	if query.Cursor != "" {
+		cursor, err := query.cursor()
+		if err != nil {
+			return AIResponsePage{}, err
+		}
+		after = &rankedResponse{rank: cursor.Rank}
+		after.ID = cursor.ID
+		after.CreatedAt = cursor.CreatedAt
+		after.UsedTokens = cursor.Tokens
+	}
+
+	repository.store.mu.Lock()
+	var rows []rankedResponse
+	for _, response := range repository.store.aiResponses {
+		if response.OrganizationID != query.OrganizationID || !query.matches(repository.store, response) {
+			continue
+		}
+		row := rankedResponse{AIResponse: response, rank: query.rank(response)}
+		if after == nil || query.before(*after, row) {
+			rows = append(rows, row)
+		}
+	}
+	repository.store.mu.Unlock()
+
+	sort.Slice(rows, func(i, j int) bool {
+		return query.before(rows[i], rows[j])
+	})
+
+	page := AIResponsePage{Items: make([]models.AIResponse, 0, min(len(rows), query.Limit))}
+	for i, row := range rows {
+		if i == query.Limit {
+			last := rows[i-1]
+			page.NextCursor = encodeAIResponseCursor(aiResponseCursor{
+				Sort:      query.Sort,
+				CreatedAt: last.CreatedAt,
+				Tokens:    last.UsedTokens,
+				Rank:      last.rank,
+				ID:        last.ID,
+			})
+			break
+		}
+		page.Items = append(page.Items, row.AIResponse)
+	}
+	return page, nil
+}
+
+// matches applies the filters of the query to a response. The store must
+// be locked.
+func (query AIResponseQuery) matches(store *memoryStore, response models.AIResponse) bool {
+	var input struct {
+		Goal struct {
+			Type string `json:"type"`
+		} `json:"goal"`
+		CustomerProfile struct {
+			Company string `json:"company"`
+		} `json:"customer_profile"`
+	}
+	response.Input.Decode(&input)
+
+	switch {
+	case query.Channel != "" && response.Channel != query.Channel,
+		query.GoalType != "" && input.Goal.Type != query.GoalType,
+		query.CustomerCompany != "" && !strings.Contains(strings.ToLower(input.CustomerProfile.Company), strings.ToLower(query.CustomerCompany)),
+		!query.From.IsZero() && response.CreatedAt.Before(query.From),
+		!query.To.IsZero() && !response.CreatedAt.Before(query.To),
+		query.Search != "" && query.rank(response) == 0:
+		return false
+	}
+
+	if query.MinRating > 0 || query.MaxRating > 0 {
+		for _, feedback := range store.feedback {
+			if feedback.AIResponseID == response.ID && feedback.Rating != nil &&
+				(query.MinRating <= 0 || *feedback.Rating >= query.MinRating) &&
+				(query.MaxRating <= 0 || *feedback.Rating <= query.MaxRating) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// rank counts the occurrences of the search terms in the prompt and
+// response, or returns 0 unless every term occurs
+func (query AIResponseQuery) rank(response models.AIResponse) float64 {
+	if query.Search == "" {
+		return 0
+	}
+	text := strings.ToLower(response.Query + " " + response.Response)
+	occurrences := 0
+	for _, term := range strings.Fields(strings.ToLower(query.Search)) {
+		count := strings.Count(text, strings.Trim(term, `"`))
+		if count == 0 {
+			return 0
+		}
+		occurrences += count
+	}
+	return float64(occurrences)
+}
+
+// before reports whether a is listed before b in the query's sort order
+func (query AIResponseQuery) before(a rankedResponse, b rankedResponse) bool {
+	idOrder := bytes.Compare(a.ID[:], b.ID[:])
+	switch query.Sort {
+	case AIResponseSortOldest:
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return idOrder < 0
+	case AIResponseSortTokens:
+		if a.UsedTokens != b.UsedTokens {
+			return a.UsedTokens > b.UsedTokens
+		}
+	case AIResponseSortRelevance:
+		if a.rank != b.rank {
+			return a.rank > b.rank
+		}
+	default:
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+	}
+	return idOrder > 0
+}
+
+type memoryFeedback struct {
+	store *memoryStore
+}
+
+func (repository *memoryFeedback) Create(feedback *models.AIResponseFeedback) error {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	feedback.ID = uuid.New()
+	feedback.CreatedAt = time.Now()
+	feedback.UpdatedAt = feedback.CreatedAt
+	repository.store.feedback = append(repository.store.feedback, *feedback)
+	return nil
+}
+
+func (repository *memoryFeedback) List(query FeedbackQuery) (FeedbackPage, error) {
+	cursor, err := query.normalize()
+	if err != nil {
+		return FeedbackPage{}, err
+	}
+
+	// newer orders by creation time, then ID, newest first
+	newer := func(aCreatedAt time.Time, aID uuid.UUID, bCreatedAt time.Time, bID uuid.UUID) bool {
+		if !aCreatedAt.Equal(bCreatedAt) {
+			return aCreatedAt.After(bCreatedAt)
+		}
+		return bytes.Compare(aID[:], bID[:]) > 0
+	}
+
+	repository.store.mu.Lock()
+	feedback := []models.AIResponseFeedback{}
+	for _, entry := range repository.store.feedback {
+		if query.matches(entry) && (cursor == nil || newer(cursor.CreatedAt, cursor.ID, entry.CreatedAt, entry.ID)) {
+			feedback = append(feedback, entry)
+		}
+	}
+	repository.store.mu.Unlock()
+
+	sort.Slice(feedback, func(i, j int) bool {
+		return newer(feedback[i].CreatedAt, feedback[i].ID, feedback[j].CreatedAt, feedback[j].ID)
+	})
+	return feedbackPage(feedback[:min(len(feedback), query.Limit+1)], query.Limit), nil
+}
+
+func (repository *memoryFeedback) Summary(filter FeedbackFilter) (FeedbackSummary, error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	summary := newFeedbackSummary()
+	ratingTotal := 0
+	for _, entry := range repository.store.feedback {
+		if !filter.matches(entry) {
+			continue
+		}
+		summary.Count++
+		if entry.Rating != nil {
+			summary.RatedCount++
+			summary.Ratings[*entry.Rating]++
+			ratingTotal += *entry.Rating
+		}
+		if entry.SelectedIndex != nil {
+			summary.Selections[*entry.SelectedIndex]++
+		}
+		if entry.EditedText != "" {
+			summary.EditedCount++
+		}
+
+		var votes []struct {
+			Vote string `json:"vote"`
+		}
+		if len(entry.MessageVotes) > 0 && entry.MessageVotes.Decode(&votes) == nil {
+			for _, vote := range votes {
+				switch vote.Vote {
+				case models.FeedbackVoteUp:
+					summary.UpVotes++
+				case models.FeedbackVoteDown:
+					summary.DownVotes++
+				}
+			}
+		}
+
+		var tags []string
+		if len(entry.ReasonTags) > 0 && entry.ReasonTags.Decode(&tags) == nil {
+			for _, tag := range tags {
+				summary.ReasonTags[tag]++
+			}
+		}
+	}
+
+	if summary.RatedCount > 0 {
+		summary.AverageRating = float64(ratingTotal) / float64(summary.RatedCount)
+	}
+	return summary, nil
+}
+
+// matches applies the filter to a feedback entry
+func (filter FeedbackFilter) matches(entry models.AIResponseFeedback) bool {
+	return entry.OrganizationID == filter.OrganizationID &&
+		(filter.AIResponseID == nil || entry.AIResponseID == *filter.AIResponseID) &&
+		(filter.From.IsZero() || !entry.CreatedAt.Before(filter.From)) &&
+		(filter.To.IsZero() || entry.CreatedAt.Before(filter.To))
+}
+
+// goalType returns the goal type of a response's input
+func goalType(response models.AIResponse) string {
+	var input struct {
+		Goal struct {
+			Type string `json:"type"`
+		} `json:"goal"`
+	}
+	response.Input.Decode(&input)
+	return input.Goal.Type
+}
+
+// feedbackExamples pairs every feedback entry with the response it rates,
+// in the order the feedback was given. The store must be locked.
+func (store *memoryStore) feedbackExamples() []memoryFeedbackExample {
+	responses := make(map[uuid.UUID]models.AIResponse, len(store.aiResponses))
+	for _, response := range store.aiResponses {
+		responses[response.ID] = response
+	}
+
+	var examples []memoryFeedbackExample
+	for _, feedback := range store.feedback {
+		if response, ok := responses[feedback.AIResponseID]; ok {
+			examples = append(examples, memoryFeedbackExample{feedback: feedback, response: response})
+		}
+	}
+	return examples
+}
+
+type memoryFeedbackExample struct {
+	feedback models.AIResponseFeedback
+	response models.AIResponse
+}
+
+func (example memoryFeedbackExample) export() FeedbackExample {
+	return FeedbackExample{
+		Query:         example.response.Query,
+		Messages:      example.response.Messages,
+		Rating:        example.feedback.Rating,
+		SelectedIndex: example.feedback.SelectedIndex,
+		EditedText:    example.feedback.EditedText,
+		MessageVotes:  example.feedback.MessageVotes,
+	}
+}
+
+func (repository *memoryFeedback) Examples(query FeedbackExampleQuery) ([]FeedbackExample, error) {
+	repository.store.mu.Lock()
+	var matches []memoryFeedbackExample
+	for _, example := range repository.store.feedbackExamples() {
+		if example.feedback.OrganizationID == query.OrganizationID &&
+			example.feedback.Rating != nil && *example.feedback.Rating >= query.MinRating &&
+			example.response.Channel == query.Channel && goalType(example.response) == query.GoalType {
+			matches = append(matches, example)
+		}
+	}
+	repository.store.mu.Unlock()
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if a, b := *matches[i].feedback.Rating, *matches[j].feedback.Rating; a != b {
+			return a > b
+		}
+		return matches[i].feedback.CreatedAt.After(matches[j].feedback.CreatedAt)
+	})
+
+	examples := []FeedbackExample{}
+	for _, match := range matches {
+		if query.Limit > 0 && len(examples) == query.Limit {
+			break
+		}
+		examples = append(examples, match.export())
+	}
+	return examples, nil
+}
+
+func (repository *memoryFeedback) Dataset(query FeedbackDatasetQuery, each func(FeedbackExample) error) error {
+	repository.store.mu.Lock()
+	var matches []memoryFeedbackExample
+	for _, example := range repository.store.feedbackExamples() {
+		response := example.response
+		if (query.OrganizationID == "" || response.OrganizationID == query.OrganizationID) &&
+			(query.Channel == "" || response.Channel == query.Channel) &&
+			(query.From.IsZero() || !response.CreatedAt.Before(query.From)) &&
+			(query.To.IsZero() || response.CreatedAt.Before(query.To)) {
+			matches = append(matches, example)
+		}
+	}
+	repository.store.mu.Unlock()
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].response.CreatedAt.Before(matches[j].response.CreatedAt)
+	})
+	for _, match := range matches {
+		if err := each(match.export()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type memoryPromptTemplates struct {
+	store *memoryStore
+}
+
+func (repository *memoryPromptTemplates) Find(organizationID string, name string, version int) (models.PromptTemplate, error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	var found *models.PromptTemplate
+	for i, template := range repository.store.promptTemplates {
+		if template.Name != name || (template.OrganizationID != organizationID && template.OrganizationID != "") ||
+			(version > 0 && template.Version != version) {
+			continue
+		}
+		// Organization templates first, then the latest version
+		if found == nil || template.OrganizationID > found.OrganizationID ||
+			(template.OrganizationID == found.OrganizationID && template.Version > found.Version) {
+			found = &repository.store.promptTemplates[i]
+		}
+	}
+	if found == nil {
+		return models.PromptTemplate{}, ErrNotFound
+	}
+	return *found, nil
+}
+
+func (repository *memoryPromptTemplates) List(organizationID string) ([]models.PromptTemplate, error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	templates := []models.PromptTemplate{}
+	for _, template := range repository.store.promptTemplates {
+		if template.OrganizationID == organizationID || template.OrganizationID == "" {
+			templates = append(templates, template)
+		}
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].Name != templates[j].Name {
+			return templates[i].Name < templates[j].Name
+		}
+		return templates[i].Version < templates[j].Version
+	})
+	return templates, nil
+}
+
+func (repository *memoryPromptTemplates) Create(template *models.PromptTemplate, minVersion int) error {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	latest := minVersion
+	for _, stored := range repository.store.promptTemplates {
+		if stored.OrganizationID == template.OrganizationID && stored.Name == template.Name {
+			latest = max(latest, stored.Version)
+		}
+	}
+	template.Version = latest + 1
+	template.ID = uuid.New()
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = template.CreatedAt
+	repository.store.promptTemplates = append(repository.store.promptTemplates, *template)
+	return nil
+}
+
+type memoryAPIKeys struct {
+	store *memoryStore
+}
+
+func (repository *memoryAPIKeys) Create(apiKey *models.APIKey) error {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	repository.store.createAPIKey(apiKey)
+	return nil
+}
+
+// createAPIKey stores a key. The store must be locked.
+func (store *memoryStore) createAPIKey(apiKey *models.APIKey) {
+	apiKey.ID = uuid.New()
+	apiKey.CreatedAt = time.Now()
+	apiKey.UpdatedAt = apiKey.CreatedAt
+	store.apiKeys = append(store.apiKeys, *apiKey)
+}
+
+func (repository *memoryAPIKeys) List(organizationID string) ([]models.APIKey, error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	apiKeys := []models.APIKey{}
+	for _, apiKey := range repository.store.apiKeys {
+		if apiKey.OrganizationID == organizationID {
+			apiKeys = append(apiKeys, apiKey)
+		}
+	}
+	return apiKeys, nil
+}
+
+func (repository *memoryAPIKeys) FindActive(keyHash string) (models.APIKey, error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for _, apiKey := range repository.store.apiKeys {
+		if apiKey.KeyHash == keyHash && apiKey.RevokedAt == nil {
+			return apiKey, nil
+		}
+	}
+	return models.APIKey{}, ErrNotFound
+}
+
+func (repository *memoryAPIKeys) Revoke(organizationID string, id uuid.UUID) (models.APIKey, error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	return repository.store.revokeAPIKey(organizationID, id)
+}
+
+// revokeAPIKey disables an active key. The store must be locked.
+func (store *memoryStore) revokeAPIKey(organizationID string, id uuid.UUID) (models.APIKey, error) {
+	for i, apiKey := range store.apiKeys {
+		if apiKey.OrganizationID == organizationID && apiKey.ID == id && apiKey.RevokedAt == nil {
+			now := time.Now()
+			store.apiKeys[i].RevokedAt = &now
+			return store.apiKeys[i], nil
+		}
+	}
+	return models.APIKey{}, ErrNotFound
+}
+
+func (repository *memoryAPIKeys) Rotate(organizationID string, id uuid.UUID, replacement *models.APIKey) (models.APIKey, error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	previous, err := repository.store.revokeAPIKey(organizationID, id)
+	if err != nil {
+		return previous, err
+	}
+	replacement.Name = previous.Name
+	repository.store.createAPIKey(replacement)
+	return previous, nil
+}
+
+func (repository *memoryAPIKeys) Touch(apiKey *models.APIKey, usedAt time.Time) error {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for i := range repository.store.apiKeys {
+		if repository.store.apiKeys[i].ID == apiKey.ID {
+			repository.store.apiKeys[i].LastUsedAt = &usedAt
+			apiKey.LastUsedAt = &usedAt
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+type memoryUsage struct {
+	store *memoryStore
+}
+
+func (repository *memoryUsage) Create(record *models.UsageRecord) error {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	record.ID = uuid.New()
+	record.CreatedAt = time.Now()
+	record.UpdatedAt = record.CreatedAt
+	repository.store.usage = append(repository.store.usage, *record)
+	return nil
+}
+
+func (repository *memoryUsage) TokensUsedSince(organizationID string, since time.Time) (int64, error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	var used int64
+	for _, record := range repository.store.usage {
+		if record.OrganizationID == organizationID && !record.CreatedAt.Before(since) {
+			used += record.TotalTokens
+		}
+	}
+	return used, nil
+}
+
+func (repository *memoryUsage) Report(query UsageReportQuery) ([]UsageReportRow, error) {
+	groups, err := NormalizeUsageReportGroups(query.GroupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	repository.store.mu.Lock()
+	byGroup := map[UsageReportRow]*UsageReportRow{}
+	var keys []UsageReportRow
+	for _, record := range repository.store.usage {
+		if (query.OrganizationID != "" && record.OrganizationID != query.OrganizationID) ||
+			(!query.From.IsZero() && record.CreatedAt.Before(query.From)) ||
+			(!query.To.IsZero() && !record.CreatedAt.Before(query.To)) {
+			continue
+		}
+
+		var key UsageReportRow
+		for _, group := range groups {
+			switch group {
+			case "organization":
+				key.Organization = record.OrganizationID
+			case "day":
+				key.Day = record.CreatedAt.Format(time.DateOnly)
+			case "channel":
+				key.Channel = record.Channel
+			case "model":
+				key.Model = record.ModelName
+			case "operation":
+				key.Operation = record.Operation
+			}
+		}
+		row, ok := byGroup[key]
+		if !ok {
+			row = &UsageReportRow{Organization: key.Organization, Day: key.Day, Channel: key.Channel, Model: key.Model, Operation: key.Operation}
+			byGroup[key] = row
+			keys = append(keys, key)
+		}
+		row.Calls++
+		row.PromptTokens += record.PromptTokens
+		row.CompletionTokens += record.CompletionTokens
+		row.TotalTokens += record.TotalTokens
+		row.Cost += record.Cost
+	}
+	repository.store.mu.Unlock()
+
+	// Without groups the report is a single row, even over no records
+	if len(groups) == 0 && len(keys) == 0 {
+		return []UsageReportRow{{}}, nil
+	}
+	rows := make([]UsageReportRow, len(keys))
+	for i, key := range keys {
+		rows[i] = *byGroup[key]
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		a := []string{rows[i].Organization, rows[i].Day, rows[i].Channel, rows[i].Model, rows[i].Operation}
+		b := []string{rows[j].Organization, rows[j].Day, rows[j].Channel, rows[j].Model, rows[j].Operation}
+		return strings.Join(a, "\x00") < strings.Join(b, "\x00")
+	})
+	return rows, nil
+}
+
+type memoryJobs struct {
+	store *memoryStore
+}
+
+func (repository *memoryJobs) Create(job *models.GenerationJob) error {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	job.ID = uuid.New()
+	job.CreatedAt = time.Now()
+	job.UpdatedAt = job.CreatedAt
+	repository.store.jobs = append(repository.store.jobs, *job)
+	return nil
+}
+
+func (repository *memoryJobs) Get(id uuid.UUID) (models.GenerationJob, error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for _, job := range repository.store.jobs {
+		if job.ID == id {
+			return job, nil
+		}
+	}
+	return models.GenerationJob{}, ErrNotFound
+}
+
+func (repository *memoryJobs) Claim(now time.Time, staleBefore time.Time) (models.GenerationJob, error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	next := -1
+	for i, job := range repository.store.jobs {
+		runnable := (job.Status == models.JobStatusPending && !job.RunAt.After(now)) ||
+			(job.Status == models.JobStatusRunning && job.LockedAt != nil && job.LockedAt.Before(staleBefore))
+		if runnable && (next < 0 || job.RunAt.Before(repository.store.jobs[next].RunAt)) {
+			next = i
+		}
+	}
+	if next < 0 {
+		return models.GenerationJob{}, ErrNotFound
+	}
+
+	job := &repository.store.jobs[next]
+	claim(job, now)
+	job.UpdatedAt = now
+	return *job, nil
+}
+
+func (repository *memoryJobs) Save(job *models.GenerationJob) error {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	for i, stored := range repository.store.jobs {
+		if stored.ID == job.ID {
+			job.UpdatedAt = time.Now()
+			repository.store.jobs[i] = *job
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (repository *memoryJobs) Depth() (map[string]int64, error) {
+	repository.store.mu.Lock()
+	defer repository.store.mu.Unlock()
+
+	depth := map[string]int64{models.JobStatusPending: 0, models.JobStatusRunning: 0}
+	for _, job := range repository.store.jobs {
+		if _, ok := depth[job.Status]; ok {
+			depth[job.Status]++
+		}
+	}
+	return depth, nil
+}
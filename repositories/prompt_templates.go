@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	models "go-server/models"
+
+	"gorm.io/gorm"
+)
+
+type gormPromptTemplates struct {
+	db *gorm.DB
+}
+
+func (repository *gormPromptTemplates) withContext(ctx context.Context) PromptTemplateRepository {
+	return &gormPromptTemplates{db: repository.db.WithContext(ctx)}
+}
+
+func (repository *gormPromptTemplates) Find(organizationID string, name string, version int) (models.PromptTemplate, error) {
+	query := repository.db.Where("name = ? AND organization_id IN ?", name, []string{organizationID, ""})
+	if version > 0 {
+		query = query.Where("version = ?", version)
+	}
+
+	var template models.PromptTemplate
+	err := query.Order("organization_id DESC, version DESC").First(&template).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return template, ErrNotFound
+	}
+	if err != nil {
+		return template, fmt.Errorf("failed to load prompt template: %w", err)
+	}
+	return template, nil
+}
+
+func (repository *gormPromptTemplates) List(organizationID string) ([]models.PromptTemplate, error) {
+	templates := []models.PromptTemplate{}
+	if err := repository.db.Where("organization_id IN ?", []string{organizationID, ""}).
+		Order("name, version").Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("failed to load prompt templates: %w", err)
+	}
+	return templates, nil
+}
+
+// Create allocates the version while holding a transaction scoped
+// advisory lock on the template name, so concurrent creates queue behind
+// each other instead of colliding on the version unique index
+func (repository *gormPromptTemplates) Create(template *models.PromptTemplate, minVersion int) error {
+	return repository.db.Transaction(func(tx *gorm.DB) error {
+		lockKey := template.OrganizationID + "/" + template.Name
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", lockKey).Error; err != nil {
+			return fmt.Errorf("failed to lock prompt template versions: %w", err)
+		}
+
+		var latest int
+		if err := tx.Model(&models.PromptTemplate{}).
+			Where("organization_id = ? AND name = ?", template.OrganizationID, template.Name).
+			Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			return fmt.Errorf("failed to load prompt template versions: %w", err)
+		}
+		template.Version = max(latest, minVersion) + 1
+
+		if err := tx.Create(template).Error; err != nil {
+			return fmt.Errorf("failed to save prompt template: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,182 @@
+// Package repositories stores organization settings, AI responses and their
+// feedback, prompt templates, API keys, the usage ledger and generation
+// jobs. Every repository has a GORM implementation backed by Postgres and an
+// in-memory one for tests.
+package repositories
+
+import (
+	"context"
+	"errors"
+	models "go-server/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned when a requested record does not exist
+var ErrNotFound = errors.New("record not found")
+
+// ErrInvalidQuery is returned for listing queries with invalid parameters
+var ErrInvalidQuery = errors.New("invalid query")
+
+// SettingsRepository stores organization settings
+type SettingsRepository interface {
+	// Values returns the organization's settings by key with trimmed values
+	Values(organizationID string) (map[string]string, error)
+	List(organizationID string) ([]models.OrganizationSetting, error)
+	Get(organizationID string, key string) (models.OrganizationSetting, error)
+	// Create stores a setting, replacing the value of an existing setting
+	// with the same key
+	Create(setting *models.OrganizationSetting) error
+	Update(setting *models.OrganizationSetting) error
+}
+
+// AIResponseRepository stores generated AI responses
+type AIResponseRepository interface {
+	Create(response *models.AIResponse) error
+	Get(organizationID string, id uuid.UUID) (models.AIResponse, error)
+	List(query AIResponseQuery) (AIResponsePage, error)
+}
+
+// FeedbackRepository stores feedback on AI responses
+type FeedbackRepository interface {
+	Create(feedback *models.AIResponseFeedback) error
+	// List returns a page of the feedback matching the query, newest first
+	List(query FeedbackQuery) (FeedbackPage, error)
+	// Summary aggregates the feedback matching the filter
+	Summary(filter FeedbackFilter) (FeedbackSummary, error)
+	// Examples returns the best rated feedback matching the query, with the
+	// messages it rates, highest rating and then newest first
+	Examples(query FeedbackExampleQuery) ([]FeedbackExample, error)
+	// Dataset calls each for every feedback matching the query, with the
+	// messages it rates, in the order the responses were generated
+	Dataset(query FeedbackDatasetQuery, each func(FeedbackExample) error) error
+}
+
+// PromptTemplateRepository stores prompt template versions
+type PromptTemplateRepository interface {
+	// Find returns a template version (0 for the latest), preferring the
+	// organization's own templates over global ones
+	Find(organizationID string, name string, version int) (models.PromptTemplate, error)
+	// List returns the organization's templates and the global ones by
+	// name and version
+	List(organizationID string) ([]models.PromptTemplate, error)
+	// Create stores the template as the next version of its name: one
+	// above both the highest stored version and minVersion. Concurrent
+	// creates of the same name get distinct versions.
+	Create(template *models.PromptTemplate, minVersion int) error
+}
+
+// APIKeyRepository stores organization API keys
+type APIKeyRepository interface {
+	Create(apiKey *models.APIKey) error
+	// List returns the keys of an organization, including revoked ones,
+	// oldest first
+	List(organizationID string) ([]models.APIKey, error)
+	// FindActive returns the unrevoked key with the given hash
+	FindActive(keyHash string) (models.APIKey, error)
+	// Revoke disables an active key of the organization and returns it
+	Revoke(organizationID string, id uuid.UUID) (models.APIKey, error)
+	// Rotate revokes an active key and stores replacement under its name in
+	// the same transaction, returning the revoked key
+	Rotate(organizationID string, id uuid.UUID, replacement *models.APIKey) (models.APIKey, error)
+	// Touch records when a key was last used
+	Touch(apiKey *models.APIKey, usedAt time.Time) error
+}
+
+// UsageRepository stores the usage ledger
+type UsageRepository interface {
+	Create(record *models.UsageRecord) error
+	// TokensUsedSince sums the tokens an organization has consumed since a
+	// time
+	TokensUsedSince(organizationID string, since time.Time) (int64, error)
+	Report(query UsageReportQuery) ([]UsageReportRow, error)
+}
+
+// JobRepository stores generation jobs
+type JobRepository interface {
+	Create(job *models.GenerationJob) error
+	Get(id uuid.UUID) (models.GenerationJob, error)
+	// Claim marks the next runnable job as running and returns it: a
+	// pending job that is due, or a running one locked before staleBefore
+	// by a worker that crashed. It returns ErrNotFound when no job is
+	// runnable.
+	Claim(now time.Time, staleBefore time.Time) (models.GenerationJob, error)
+	Save(job *models.GenerationJob) error
+	// Depth counts the pending and running jobs by status
+	Depth() (map[string]int64, error)
+}
+
+// Repositories bundles the repositories a server runs with
+type Repositories struct {
+	Settings        SettingsRepository
+	AIResponses     AIResponseRepository
+	Feedback        FeedbackRepository
+	PromptTemplates PromptTemplateRepository
+	APIKeys         APIKeyRepository
+	Usage           UsageRepository
+	Jobs            JobRepository
+
+	// db is nil for in-memory repositories
+	db *gorm.DB
+}
+
+// Ping checks that the database is reachable. In-memory repositories are
+// always reachable.
+func (repos Repositories) Ping(ctx context.Context) error {
+	if repos.db == nil {
+		return nil
+	}
+	sqlDB, err := repos.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// WithContext returns repositories whose queries run with ctx, so that they
+// stop when it is cancelled and are traced as part of its span
+func (repos Repositories) WithContext(ctx context.Context) Repositories {
+	if repos.db == nil {
+		return repos
+	}
+	return Repositories{
+		Settings:        withContext(repos.Settings, ctx),
+		AIResponses:     withContext(repos.AIResponses, ctx),
+		Feedback:        withContext(repos.Feedback, ctx),
+		PromptTemplates: withContext(repos.PromptTemplates, ctx),
+		APIKeys:         withContext(repos.APIKeys, ctx),
+		Usage:           withContext(repos.Usage, ctx),
+		Jobs:            withContext(repos.Jobs, ctx),
+		db:              repos.db.WithContext(ctx),
+	}
+}
+
+// contextual is implemented by repositories that can run their queries
+// with a context
+type contextual[T any] interface {
+	withContext(ctx context.Context) T
+}
+
+func withContext[T any](repository T, ctx context.Context) T {
+	if contextual, ok := any(repository).(contextual[T]); ok {
+		return contextual.withContext(ctx)
+	}
+	return repository
+}
+
+// NewGorm returns repositories backed by db. Settings values are cached for
+// settingsCacheTTL; writes through the repository invalidate the cache.
+func NewGorm(db *gorm.DB, settingsCacheTTL time.Duration) Repositories {
+	return Repositories{
+		Settings:        NewCachedSettings(&gormSettings{db: db}, settingsCacheTTL),
+		AIResponses:     &gormAIResponses{db: db},
+		Feedback:        &gormFeedback{db: db},
+		PromptTemplates: &gormPromptTemplates{db: db},
+		APIKeys:         &gormAPIKeys{db: db},
+		Usage:           &gormUsage{db: db},
+		Jobs:            &gormJobs{db: db},
+		db:              db,
+	}
+}
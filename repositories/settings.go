@@ -0,0 +1,144 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	models "go-server/models"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormSettings struct {
+	db *gorm.DB
+}
+
+func (repository *gormSettings) withContext(ctx context.Context) SettingsRepository {
+	return &gormSettings{db: repository.db.WithContext(ctx)}
+}
+
+func (repository *gormSettings) Values(organizationID string) (map[string]string, error) {
+	settings, err := repository.List(organizationID)
+	if err != nil {
+		return nil, err
+	}
+	return settingValues(settings), nil
+}
+
+func (repository *gormSettings) List(organizationID string) ([]models.OrganizationSetting, error) {
+	settings := []models.OrganizationSetting{}
+	if err := repository.db.Where(&models.OrganizationSetting{OrganizationID: organizationID}).Find(&settings).Error; err != nil {
+		return nil, fmt.Errorf("failed to load organization settings: %w", err)
+	}
+	return settings, nil
+}
+
+func (repository *gormSettings) Get(organizationID string, key string) (models.OrganizationSetting, error) {
+	var setting models.OrganizationSetting
+	err := repository.db.First(&setting, "organization_id = ? AND key = ?", organizationID, key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return setting, ErrNotFound
+	}
+	if err != nil {
+		return setting, fmt.Errorf("failed to load organization setting: %w", err)
+	}
+	return setting, nil
+}
+
+func (repository *gormSettings) Create(setting *models.OrganizationSetting) error {
+	err := repository.db.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "organization_id"}, {Name: "key"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL"}}},
+		DoUpdates:   clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(setting).Error
+	if err != nil {
+		return fmt.Errorf("failed to save organization setting: %w", err)
+	}
+	return nil
+}
+
+func (repository *gormSettings) Update(setting *models.OrganizationSetting) error {
+	if err := repository.db.Save(setting).Error; err != nil {
+		return fmt.Errorf("failed to save organization setting: %w", err)
+	}
+	return nil
+}
+
+func settingValues(settings []models.OrganizationSetting) map[string]string {
+	values := make(map[string]string, len(settings))
+	for _, setting := range settings {
+		values[setting.Key] = strings.TrimSpace(setting.Value)
+	}
+	return values
+}
+
+type settingsCacheEntry struct {
+	values  map[string]string
+	expires time.Time
+}
+
+// cachedSettings caches Values per organization
+type cachedSettings struct {
+	SettingsRepository
+	cache *settingsCache
+}
+
+// settingsCache is shared by a cachedSettings and its copies bound to a
+// context
+type settingsCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]settingsCacheEntry
+}
+
+// NewCachedSettings caches the Values of settings for ttl. Creates and
+// updates through the returned repository invalidate the organization's
+// entry immediately; writes made elsewhere show up once it expires.
+func NewCachedSettings(settings SettingsRepository, ttl time.Duration) SettingsRepository {
+	return &cachedSettings{SettingsRepository: settings, cache: &settingsCache{ttl: ttl, entries: map[string]settingsCacheEntry{}}}
+}
+
+func (repository *cachedSettings) withContext(ctx context.Context) SettingsRepository {
+	return &cachedSettings{SettingsRepository: withContext(repository.SettingsRepository, ctx), cache: repository.cache}
+}
+
+func (repository *cachedSettings) Values(organizationID string) (map[string]string, error) {
+	cache := repository.cache
+	cache.mu.Lock()
+	entry, ok := cache.entries[organizationID]
+	cache.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.values, nil
+	}
+
+	values, err := repository.SettingsRepository.Values(organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.mu.Lock()
+	cache.entries[organizationID] = settingsCacheEntry{values: values, expires: time.Now().Add(cache.ttl)}
+	cache.mu.Unlock()
+	return values, nil
+}
+
+func (repository *cachedSettings) Create(setting *models.OrganizationSetting) error {
+	defer repository.cache.invalidate(setting.OrganizationID)
+	return repository.SettingsRepository.Create(setting)
+}
+
+func (repository *cachedSettings) Update(setting *models.OrganizationSetting) error {
+	defer repository.cache.invalidate(setting.OrganizationID)
+	return repository.SettingsRepository.Update(setting)
+}
+
+func (cache *settingsCache) invalidate(organizationID string) {
+	cache.mu.Lock()
+	delete(cache.entries, organizationID)
+	cache.mu.Unlock()
+}
@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	models "go-server/models"
+	"testing"
+)
+
+// testSettingsCreateReplacesValues writes the same key twice and checks a
+// single row holds the latest value
+func testSettingsCreateReplacesValues(t *testing.T, repos Repositories) {
+	first := &models.OrganizationSetting{OrganizationID: "org", Key: "quota.daily_tokens", Value: "1000"}
+	if err := repos.Settings.Create(first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repos.Settings.Create(&models.OrganizationSetting{OrganizationID: "other", Key: "quota.daily_tokens", Value: "5"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second := &models.OrganizationSetting{OrganizationID: "org", Key: "quota.daily_tokens", Value: "2000"}
+	if err := repos.Settings.Create(second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second.ID != first.ID {
+		t.Errorf("expected the existing setting %s to be updated, got %s", first.ID, second.ID)
+	}
+
+	settings, err := repos.Settings.List("org")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(settings) != 1 || settings[0].Value != "2000" {
+		t.Errorf("expected a single row with the latest value, got %+v", settings)
+	}
+	if setting, err := repos.Settings.Get("org", "quota.daily_tokens"); err != nil || setting.Value != "2000" {
+		t.Errorf("expected the latest value, got %q (%v)", setting.Value, err)
+	}
+	if values, err := repos.Settings.Values("other"); err != nil || values["quota.daily_tokens"] != "5" {
+		t.Errorf("expected other organizations to keep their value, got %v (%v)", values, err)
+	}
+}
+
+func TestMemorySettingsCreateReplacesValues(t *testing.T) {
+	testSettingsCreateReplacesValues(t, NewMemory())
+}
+
+func TestGormSettingsCreateReplacesValues(t *testing.T) {
+	testSettingsCreateReplacesValues(t, openTestRepositories(t))
+}
@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	models "go-server/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// usageReportGroups maps the group_by values of a usage report to the
+// ledger expression they group on
+var usageReportGroups = map[string]string{
+	"organization": "organization_id",
+	"channel":      "channel",
+	"model":        "model_name",
+	"operation":    "operation",
+	"day":          "to_char(date_trunc('day', created_at), 'YYYY-MM-DD')",
+}
+
+// usageReportGroupOrder is the column order of grouped reports
+var usageReportGroupOrder = []string{"organization", "day", "channel", "model", "operation"}
+
+// UsageReportQuery selects and groups ledger records. Zero times leave the
+// range open.
+type UsageReportQuery struct {
+	OrganizationID string
+	From           time.Time
+	To             time.Time
+	GroupBy        []string
+}
+
+// UsageReportRow is one group of a usage report. Columns that are not
+// grouped on are empty.
+type UsageReportRow struct {
+	Organization     string  `json:"organization_id,omitempty"`
+	Day              string  `json:"day,omitempty"`
+	Channel          string  `json:"channel,omitempty"`
+	Model            string  `json:"model,omitempty"`
+	Operation        string  `json:"operation,omitempty"`
+	Calls            int64   `json:"calls"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// NormalizeUsageReportGroups validates group_by values and returns them in
+// report column order
+func NormalizeUsageReportGroups(groups []string) ([]string, error) {
+	requested := map[string]bool{}
+	for _, group := range groups {
+		group = strings.ToLower(strings.TrimSpace(group))
+		if group == "" {
+			continue
+		}
+		if _, ok := usageReportGroups[group]; !ok {
+			return nil, fmt.Errorf("%w: unknown group %q", ErrInvalidQuery, group)
+		}
+		requested[group] = true
+	}
+
+	normalized := []string{}
+	for _, group := range usageReportGroupOrder {
+		if requested[group] {
+			normalized = append(normalized, group)
+		}
+	}
+	return normalized, nil
+}
+
+type gormUsage struct {
+	db *gorm.DB
+}
+
+func (repository *gormUsage) withContext(ctx context.Context) UsageRepository {
+	return &gormUsage{db: repository.db.WithContext(ctx)}
+}
+
+func (repository *gormUsage) Create(record *models.UsageRecord) error {
+	if err := repository.db.Create(record).Error; err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	return nil
+}
+
+func (repository *gormUsage) TokensUsedSince(organizationID string, since time.Time) (int64, error) {
+	var used int64
+	if err := repository.db.Model(&models.UsageRecord{}).
+		Where("organization_id = ? AND created_at >= ?", organizationID, since).
+		Select("COALESCE(SUM(total_tokens), 0)").Scan(&used).Error; err != nil {
+		return 0, fmt.Errorf("failed to load token usage: %w", err)
+	}
+	return used, nil
+}
+
+// Report aggregates the usage ledger
+func (repository *gormUsage) Report(query UsageReportQuery) ([]UsageReportRow, error) {
+	groups, err := NormalizeUsageReportGroups(query.GroupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := []string{
+		"COUNT(*) AS calls",
+		"COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens",
+		"COALESCE(SUM(completion_tokens), 0) AS completion_tokens",
+		"COALESCE(SUM(total_tokens), 0) AS total_tokens",
+		"COALESCE(SUM(cost), 0) AS cost",
+	}
+	expressions := make([]string, len(groups))
+	for i, group := range groups {
+		expressions[i] = usageReportGroups[group]
+		columns = append(columns, fmt.Sprintf("%s AS %s", expressions[i], group))
+	}
+
+	db := repository.db.Model(&models.UsageRecord{}).Select(strings.Join(columns, ", "))
+	if query.OrganizationID != "" {
+		db = db.Where("organization_id = ?", query.OrganizationID)
+	}
+	if !query.From.IsZero() {
+		db = db.Where("created_at >= ?", query.From)
+	}
+	if !query.To.IsZero() {
+		db = db.Where("created_at < ?", query.To)
+	}
+	if len(expressions) > 0 {
+		db = db.Group(strings.Join(expressions, ", ")).Order(strings.Join(expressions, ", "))
+	}
+
+	rows := []UsageReportRow{}
+	if err := db.Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load usage report: %w", err)
+	}
+	return rows, nil
+}
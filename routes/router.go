@@ -3,31 +3,90 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 
+	"go-server/config"
 	controllers "go-server/controllers"
+	"go-server/metrics"
+	"go-server/middleware"
+	"go-server/tracing"
 )
 
-func SetupRouter() *gin.Engine {
-	router := gin.Default()
+// SetupRouter registers the API routes on the handlers of server
+func SetupRouter(server *controllers.Server) *gin.Engine {
+	router := gin.New()
+	router.Use(
+		gin.Recovery(),
+		tracing.Middleware(config.LoadTracingConfig().ServiceName),
+		middleware.RequestLogger(server.Repositories),
+		metrics.Middleware(),
+	)
 
 	// health check
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"message": "OK"})
 	})
 
-	// add v1 prefix
-	v1 := router.Group("/api/v1")
+	// readiness check: database and model endpoint availability
+	router.GET("/ready", server.GetReadiness)
+
+	// Prometheus metrics
+	router.GET("/metrics", metrics.Handler())
+
+	// add v1 prefix; every API route requires an API key and is rate
+	// limited per organization, and routes with an :organizationId only
+	// accept keys of that organization
+	v1 := router.Group("/api/v1", middleware.RequireAPIKey(server.Repositories), middleware.RateLimit(server.Repositories))
+	organizationScoped := middleware.RequireOrganizationParam("organizationId")
+	tokenQuota := middleware.RequireTokenQuota(server.Repositories)
 
 	// Settings routes
-	v1.POST("/settings", controllers.CreateOrganizationSetting)
-	v1.GET("/settings/:organizationId", controllers.GetOrganizationSettings)
-	v1.GET("/settings/:organizationId/:key", controllers.GetOrganizationSetting)
-	v1.PUT("/settings/:organizationId", controllers.UpdateOrganizationSetting)
+	v1.POST("/settings", server.CreateOrganizationSetting)
+	v1.GET("/settings/:organizationId", organizationScoped, server.GetOrganizationSettings)
+	v1.GET("/settings/:organizationId/:key", organizationScoped, server.GetOrganizationSetting)
+	v1.PUT("/settings/:organizationId", organizationScoped, server.UpdateOrganizationSetting)
 
 	// AI Response routes
-	v1.POST("/ai-responses", controllers.CreateAIResponse)
-	v1.GET("/ai-responses/:organizationId", controllers.GetOrganizationAIResponses)
-	v1.GET("/ai-responses/:organizationId/:id", controllers.GetOrganizationAIResponse)
-	v1.POST("/ai-responses/:organizationId/:id/feedback", controllers.CreateAIResponseFeedback)
+	v1.POST("/ai-responses", tokenQuota, server.CreateAIResponse)
+	v1.POST("/ai-responses/stream", tokenQuota, server.CreateAIResponseStream)
+	v1.POST("/ai-responses/batch", tokenQuota, server.CreateAIResponseBatch)
+	v1.POST("/ai-responses/linkedin", tokenQuota, server.CreateAIResponseFromLinkedIn)
+	v1.GET("/ai-responses/:organizationId", organizationScoped, server.GetOrganizationAIResponses)
+	v1.GET("/ai-responses/:organizationId/:id", organizationScoped, server.GetOrganizationAIResponse)
+	v1.POST("/ai-responses/:organizationId/:id/feedback", organizationScoped, server.CreateAIResponseFeedback)
+	v1.GET("/ai-responses/:organizationId/:id/feedback", organizationScoped, server.GetAIResponseFeedback)
+	v1.GET("/ai-responses/:organizationId/:id/feedback/summary", organizationScoped, server.GetAIResponseFeedbackSummary)
+
+	// Feedback routes
+	v1.GET("/feedback/:organizationId", organizationScoped, server.GetOrganizationFeedback)
+	v1.GET("/feedback/:organizationId/summary", organizationScoped, server.GetOrganizationFeedbackSummary)
+
+	// Prompt template routes
+	v1.POST("/prompt-templates", server.CreatePromptTemplate)
+	v1.GET("/prompt-templates", server.GetPromptTemplates)
+	v1.GET("/prompt-templates/:name", server.GetPromptTemplate)
+
+	// Business info routes
+	v1.POST("/business-info/extract", tokenQuota, server.ExtractBusinessInfo)
+
+	// API key routes
+	v1.POST("/api-keys", server.CreateAPIKey)
+	v1.GET("/api-keys/:organizationId", organizationScoped, server.GetOrganizationAPIKeys)
+	v1.POST("/api-keys/:organizationId/:id/rotate", organizationScoped, server.RotateAPIKey)
+	v1.DELETE("/api-keys/:organizationId/:id", organizationScoped, server.RevokeAPIKey)
+
+	// Usage routes
+	v1.GET("/usage/:organizationId", organizationScoped, server.GetOrganizationUsage)
+	v1.GET("/usage-reports", server.GetUsageReport)
+
+	// Export routes
+	v1.GET("/exports/fine-tuning", server.ExportFineTuningDataset)
+
+	// Generation job routes
+	v1.POST("/jobs", tokenQuota, server.CreateGenerationJob)
+	v1.GET("/jobs/:id", server.GetGenerationJob)
+
+	// Admin routes
+	admin := v1.Group("/admin", middleware.RequireAdmin())
+	admin.GET("/endpoints", server.GetModelEndpoints)
 
 	return router
 }
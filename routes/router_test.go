@@ -0,0 +1,881 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"go-server/config"
+	"go-server/controllers"
+	"go-server/jobs"
+	"go-server/logging"
+	"go-server/models"
+	"go-server/repositories"
+	aiTool "go-server/tools/ai-tool"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	testAdminKey       = "test-admin-key"
+	testOrganizationID = "6f1c1f53-9d43-4c4e-9a67-2f0b8f0f8a01"
+)
+
+const testMessages = `{"messages":[{"message":"Hi Jane, quick demo?","score":0.9},{"message":"Jane, can we talk?","score":0.7}]}`
+
+// newTestServer returns a server with in-memory repositories whose model
+// calls are answered by the returned fake provider
+func newTestServer(t *testing.T) (*controllers.Server, *aiTool.FakeProvider) {
+	t.Helper()
+	t.Setenv("ADMIN_API_KEY", testAdminKey)
+	gin.SetMode(gin.TestMode)
+
+	provider := aiTool.NewFakeProvider()
+	provider.Content = testMessages
+	return controllers.NewServer(repositories.NewMemory(), aiTool.NewStaticResolver(provider)), provider
+}
+
+func newTestRouter(t *testing.T) (*gin.Engine, *aiTool.FakeProvider) {
+	t.Helper()
+	server, provider := newTestServer(t)
+	return SetupRouter(server), provider
+}
+
+// do sends an admin request and decodes the JSON response into out
+func do(t *testing.T, router *gin.Engine, method string, path string, body interface{}, wantStatus int, out interface{}) {
+	t.Helper()
+	doAs(t, router, testAdminKey, method, path, body, wantStatus, out)
+}
+
+// doAs sends a request with an API key and decodes the JSON response into
+// out
+func doAs(t *testing.T, router *gin.Engine, apiKey string, method string, path string, body interface{}, wantStatus int, out interface{}) {
+	t.Helper()
+	var reader bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reader).Encode(body); err != nil {
+			t.Fatalf("failed to encode request: %v", err)
+		}
+	}
+	request := httptest.NewRequest(method, path, &reader)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-API-Key", apiKey)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != wantStatus {
+		t.Fatalf("%s %s: expected status %d, got %d: %s", method, path, wantStatus, recorder.Code, recorder.Body.String())
+	}
+	if out != nil {
+		if err := json.Unmarshal(recorder.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: failed to decode response: %v", method, path, err)
+		}
+	}
+}
+
+func TestRouterGeneratesStoresAndCollectsFeedback(t *testing.T) {
+	router, provider := newTestRouter(t)
+
+	businessInfo := `{"company_name":"MobiloCard","industry":"Tech","core_products":["MobiloCard Pro"],"value_props":["Increase efficiency"]}`
+	do(t, router, http.MethodPost, "/api/v1/settings", gin.H{
+		"organization_id": testOrganizationID,
+		"settings":        []gin.H{{"key": "business_info", "value": businessInfo}},
+	}, http.StatusCreated, nil)
+
+	var generated struct {
+		ID       string `json:"id"`
+		Response struct {
+			Messages []struct {
+				Message string `json:"message"`
+			} `json:"messages"`
+		} `json:"response"`
+	}
+	do(t, router, http.MethodPost, "/api/v1/ai-responses", gin.H{
+		"organization_id": testOrganizationID,
+		"channel":         "email",
+		"goal":            gin.H{"type": "sales", "description": "Book product demo", "target_outcome": "Schedule a call"},
+		"customer_profile": gin.H{
+			"name": "Jane Doe", "title": "CTO", "company": "Target Corp", "industry": "Retail", "interests": []string{"AI"},
+		},
+	}, http.StatusCreated, &generated)
+
+	if generated.ID == "" || len(generated.Response.Messages) != 2 {
+		t.Fatalf("expected a stored response with 2 messages, got %+v", generated)
+	}
+	if requests := provider.Requests(); len(requests) != 1 || !bytes.Contains([]byte(requests[0].Prompt), []byte("MobiloCard")) {
+		t.Errorf("expected one prompt using the stored business info, got %d requests", len(requests))
+	}
+
+	var page struct {
+		Items []struct {
+			ID string `json:"ID"`
+		} `json:"items"`
+	}
+	do(t, router, http.MethodGet, "/api/v1/ai-responses/"+testOrganizationID+"?channel=email", nil, http.StatusOK, &page)
+	if len(page.Items) != 1 || page.Items[0].ID != generated.ID {
+		t.Fatalf("expected the generated response to be listed, got %+v", page.Items)
+	}
+	do(t, router, http.MethodGet, "/api/v1/ai-responses/"+testOrganizationID+"?cursor=not-a-cursor", nil, http.StatusBadRequest, nil)
+
+	feedbackPath := "/api/v1/ai-responses/" + testOrganizationID + "/" + generated.ID + "/feedback"
+	do(t, router, http.MethodPost, feedbackPath, gin.H{"rating": 5, "selected_index": 0}, http.StatusCreated, nil)
+	do(t, router, http.MethodPost, feedbackPath, gin.H{"selected_index": 2}, http.StatusBadRequest, nil)
+
+	var summary struct {
+		Count         int     `json:"count"`
+		AverageRating float64 `json:"average_rating"`
+	}
+	do(t, router, http.MethodGet, "/api/v1/feedback/"+testOrganizationID+"/summary", nil, http.StatusOK, &summary)
+	if summary.Count != 1 || summary.AverageRating != 5 {
+		t.Errorf("expected one 5 star rating, got %+v", summary)
+	}
+	do(t, router, http.MethodGet, "/api/v1/feedback/"+testOrganizationID+"/summary?from=2999-01-01", nil, http.StatusOK, &summary)
+	if summary.Count != 0 {
+		t.Errorf("expected no feedback from the future, got %+v", summary)
+	}
+
+	var feedback struct {
+		Items []struct {
+			AIResponseID string `json:"AIResponseID"`
+		} `json:"items"`
+		NextCursor string `json:"next_cursor"`
+	}
+	do(t, router, http.MethodGet, "/api/v1/feedback/"+testOrganizationID+"?limit=1", nil, http.StatusOK, &feedback)
+	if len(feedback.Items) != 1 || feedback.Items[0].AIResponseID != generated.ID || feedback.NextCursor != "" {
+		t.Errorf("expected a single page with the feedback, got %+v", feedback)
+	}
+	do(t, router, http.MethodGet, feedbackPath+"?cursor=not-a-cursor", nil, http.StatusBadRequest, nil)
+	do(t, router, http.MethodGet, "/api/v1/feedback/"+testOrganizationID+"?to=yesterday", nil, http.StatusBadRequest, nil)
+
+	do(t, router, http.MethodGet, "/api/v1/ai-responses/"+testOrganizationID+"?min_rating=5", nil, http.StatusOK, &page)
+	if len(page.Items) != 1 {
+		t.Errorf("expected the rated response to match min_rating=5, got %d items", len(page.Items))
+	}
+}
+
+func TestRouterReadiness(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 without an API key, got %d", recorder.Code)
+	}
+	var readiness map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &readiness); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if readiness["ready"] != true || readiness["database"] != "ok" || readiness["model"] != "ok" || len(readiness) != 3 {
+		t.Errorf("expected only the health status of a ready server, got %v", readiness)
+	}
+
+	var endpoints struct {
+		Endpoints []struct {
+			State string `json:"state"`
+		} `json:"endpoints"`
+	}
+	do(t, router, http.MethodGet, "/api/v1/admin/endpoints", nil, http.StatusOK, &endpoints)
+	if len(endpoints.Endpoints) != 1 || endpoints.Endpoints[0].State != "closed" {
+		t.Errorf("expected a closed endpoint, got %+v", endpoints)
+	}
+
+	var issued struct {
+		Key string `json:"key"`
+	}
+	do(t, router, http.MethodPost, "/api/v1/api-keys", gin.H{"organization_id": testOrganizationID, "name": "ci"}, http.StatusCreated, &issued)
+	doAs(t, router, issued.Key, http.MethodGet, "/api/v1/admin/endpoints", nil, http.StatusForbidden, nil)
+}
+
+func TestRouterMetrics(t *testing.T) {
+	router, _ := newTestRouter(t)
+	do(t, router, http.MethodGet, "/health", nil, http.StatusOK, nil)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", recorder.Code)
+	}
+	if !bytes.Contains(recorder.Body.Bytes(), []byte(`mobilo_http_requests_total{method="GET",route="/health",status="200"}`)) {
+		t.Errorf("expected the health check to be counted, got:\n%s", recorder.Body.String())
+	}
+}
+
+func TestRouterLogsRequestsWithRedaction(t *testing.T) {
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(logging.NewHandler(&logs, slog.LevelInfo)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+	router, _ := newTestRouter(t)
+
+	generate := gin.H{
+		"organization_id":  testOrganizationID,
+		"channel":          "sms",
+		"goal":             gin.H{"type": "sales", "description": "Book product demo", "target_outcome": "Schedule a call"},
+		"customer_profile": gin.H{"name": "Jane Doe", "title": "CTO", "company": "Target Corp", "industry": "Retail", "interests": []string{"AI"}},
+		"business_info":    gin.H{"company_name": "MobiloCard", "industry": "Tech", "core_products": []string{"MobiloCard Pro"}, "value_props": []string{"Speed"}},
+	}
+	body, _ := json.Marshal(generate)
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/ai-responses", bytes.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-API-Key", testAdminKey)
+	request.Header.Set("X-Request-ID", "req-123")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusCreated || recorder.Header().Get("X-Request-ID") != "req-123" {
+		t.Fatalf("expected the request ID to be echoed on a 201, got %d %q", recorder.Code, recorder.Header().Get("X-Request-ID"))
+	}
+
+	var line map[string]interface{}
+	for _, raw := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		if err := json.Unmarshal([]byte(raw), &line); err != nil {
+			t.Fatalf("expected JSON log lines, got %q", raw)
+		}
+		if line["request_id"] != "req-123" || line["organization_id"] != testOrganizationID || line["route"] != "/api/v1/ai-responses" {
+			t.Errorf("expected request fields on every line, got %v", line)
+		}
+	}
+	if strings.Contains(logs.String(), "Jane Doe") {
+		t.Errorf("expected customer data to stay out of the logs, got:\n%s", logs.String())
+	}
+
+	do(t, router, http.MethodPost, "/api/v1/settings", gin.H{
+		"organization_id": testOrganizationID,
+		"settings":        []gin.H{{"key": "logging.debug", "value": "true"}},
+	}, http.StatusCreated, nil)
+	logs.Reset()
+	do(t, router, http.MethodPost, "/api/v1/ai-responses", generate, http.StatusCreated, nil)
+	if !strings.Contains(logs.String(), `"customer_name":"Jane Doe"`) {
+		t.Errorf("expected debug logs with the customer name once logging.debug is set, got:\n%s", logs.String())
+	}
+}
+
+func TestRouterStreamsEventsAndReportsEarlyErrorsAsJSON(t *testing.T) {
+	router, provider := newTestRouter(t)
+	t.Setenv("AI_MAX_ATTEMPTS", "1")
+
+	generate := gin.H{
+		"organization_id":  testOrganizationID,
+		"channel":          "sms",
+		"goal":             gin.H{"type": "sales", "description": "Book product demo", "target_outcome": "Schedule a call"},
+		"customer_profile": gin.H{"name": "Jane Doe", "title": "CTO", "company": "Target Corp", "industry": "Retail", "interests": []string{"AI"}},
+		"business_info":    gin.H{"company_name": "MobiloCard", "industry": "Tech", "core_products": []string{"MobiloCard Pro"}, "value_props": []string{"Speed"}},
+	}
+	stream := func() *httptest.ResponseRecorder {
+		body, _ := json.Marshal(generate)
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/ai-responses/stream", bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("X-API-Key", testAdminKey)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := stream()
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/event-stream") {
+		t.Fatalf("expected an event stream, got %d %q", recorder.Code, contentType)
+	}
+	if !strings.Contains(recorder.Body.String(), "event:message") || !strings.Contains(recorder.Body.String(), "event:done") {
+		t.Errorf("expected message and done events, got:\n%s", recorder.Body.String())
+	}
+
+	provider.Err = errors.New("provider unavailable")
+	recorder = stream()
+	if recorder.Code != http.StatusInternalServerError || !strings.HasPrefix(recorder.Header().Get("Content-Type"), "application/json") {
+		t.Fatalf("expected a JSON 500 before the first event, got %d %q", recorder.Code, recorder.Header().Get("Content-Type"))
+	}
+	if recorder.Header().Get("Cache-Control") == "no-cache" {
+		t.Errorf("expected no event stream headers on an early error")
+	}
+}
+
+func TestRouterRejectsInvalidGenerationInput(t *testing.T) {
+	router, provider := newTestRouter(t)
+
+	request := generationRequest()
+	delete(request, "business_info")
+	for _, path := range []string{"/api/v1/ai-responses", "/api/v1/ai-responses/stream"} {
+		do(t, router, http.MethodPost, path, request, http.StatusBadRequest, nil)
+	}
+	if len(provider.Requests()) != 0 {
+		t.Errorf("expected invalid input to never reach the provider")
+	}
+}
+
+func TestRouterBatchValidatesEveryProfile(t *testing.T) {
+	router, provider := newTestRouter(t)
+
+	valid := gin.H{"name": "Jane Doe", "title": "CTO", "company": "Target Corp", "industry": "Retail", "interests": []string{"AI"}}
+	batch := gin.H{
+		"organization_id":   testOrganizationID,
+		"channel":           "email",
+		"goal":              gin.H{"type": "sales", "description": "Book product demo", "target_outcome": "Schedule a call"},
+		"business_info":     gin.H{"company_name": "MobiloCard", "industry": "Tech", "core_products": []string{"MobiloCard Pro"}, "value_props": []string{"Speed"}},
+		"customer_profiles": []gin.H{valid, {"name": "John Roe", "title": "CEO"}},
+	}
+	do(t, router, http.MethodPost, "/api/v1/ai-responses/batch", batch, http.StatusBadRequest, nil)
+	if len(provider.Requests()) != 0 {
+		t.Errorf("expected an invalid profile to reject the whole batch before generation")
+	}
+
+	var response struct {
+		Succeeded int `json:"succeeded"`
+	}
+	batch["customer_profiles"] = []gin.H{valid}
+	do(t, router, http.MethodPost, "/api/v1/ai-responses/batch", batch, http.StatusOK, &response)
+	if response.Succeeded != 1 {
+		t.Errorf("expected the valid batch to succeed, got %+v", response)
+	}
+}
+
+func TestRouterAllocatesDistinctPromptTemplateVersions(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	const creates = 8
+	versions := make(chan int, creates)
+	var wg sync.WaitGroup
+	for range creates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var template struct {
+				Version int `json:"Version"`
+			}
+			do(t, router, http.MethodPost, "/api/v1/prompt-templates", gin.H{
+				"organization_id": testOrganizationID,
+				"name":            "default",
+				"body":            "Write {{.Variants}} {{.Channel}} messages",
+			}, http.StatusCreated, &template)
+			versions <- template.Version
+		}()
+	}
+	wg.Wait()
+	close(versions)
+
+	seen := map[int]bool{}
+	for version := range versions {
+		if version <= 3 || seen[version] {
+			t.Errorf("expected distinct versions after the built-in version 3, got %d", version)
+		}
+		seen[version] = true
+	}
+	if len(seen) != creates {
+		t.Errorf("expected %d versions, got %v", creates, seen)
+	}
+}
+
+// generationRequest returns a valid generation request for the test
+// organization
+func generationRequest() gin.H {
+	return gin.H{
+		"organization_id": testOrganizationID,
+		"channel":         "email",
+		"business_info":   gin.H{"company_name": "MobiloCard", "industry": "Tech", "core_products": []string{"MobiloCard Pro"}, "value_props": []string{"Increase efficiency"}},
+		"goal":            gin.H{"type": "sales", "description": "Book product demo", "target_outcome": "Schedule a call"},
+		"customer_profile": gin.H{
+			"name": "Jane Doe", "title": "CTO", "company": "Target Corp", "industry": "Retail", "interests": []string{"AI"},
+		},
+	}
+}
+
+func TestRouterManagesAPIKeys(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	var issued struct {
+		ID  string `json:"ID"`
+		Key string `json:"key"`
+	}
+	do(t, router, http.MethodPost, "/api/v1/api-keys", gin.H{"organization_id": testOrganizationID, "name": "ci"}, http.StatusCreated, &issued)
+	if issued.Key == "" {
+		t.Fatalf("expected the plaintext key to be returned, got %+v", issued)
+	}
+
+	var listed []struct {
+		ID string `json:"ID"`
+	}
+	keysPath := "/api/v1/api-keys/" + testOrganizationID
+	doAs(t, router, issued.Key, http.MethodGet, keysPath, nil, http.StatusOK, &listed)
+	if len(listed) != 1 || listed[0].ID != issued.ID {
+		t.Errorf("expected the issued key to be listed, got %+v", listed)
+	}
+	doAs(t, router, issued.Key, http.MethodGet, "/api/v1/api-keys/1b0e6a3e-5f5c-4d8e-9a51-0c8f3f1d2b7a", nil, http.StatusForbidden, nil)
+
+	var rotated struct {
+		ID  string `json:"ID"`
+		Key string `json:"key"`
+	}
+	doAs(t, router, issued.Key, http.MethodPost, keysPath+"/"+issued.ID+"/rotate", nil, http.StatusCreated, &rotated)
+	doAs(t, router, issued.Key, http.MethodGet, keysPath, nil, http.StatusUnauthorized, nil)
+	doAs(t, router, rotated.Key, http.MethodGet, keysPath, nil, http.StatusOK, &listed)
+	if len(listed) != 2 {
+		t.Errorf("expected the rotated and replacement keys to be listed, got %d", len(listed))
+	}
+
+	do(t, router, http.MethodDelete, keysPath+"/"+rotated.ID, nil, http.StatusOK, nil)
+	doAs(t, router, rotated.Key, http.MethodGet, keysPath, nil, http.StatusUnauthorized, nil)
+	do(t, router, http.MethodDelete, keysPath+"/"+issued.ID, nil, http.StatusNotFound, nil)
+}
+
+func TestRouterVersionsPromptTemplates(t *testing.T) {
+	router, provider := newTestRouter(t)
+
+	for _, marker := range []string{"first", "second"} {
+		do(t, router, http.MethodPost, "/api/v1/prompt-templates", gin.H{
+			"organization_id": testOrganizationID,
+			"name":            "outreach",
+			"body":            "Write {{.Variants}} " + marker + " {{.Channel}} messages to {{.CustomerProfile.Name}}",
+		}, http.StatusCreated, nil)
+	}
+
+	var template struct {
+		Version int    `json:"Version"`
+		Body    string `json:"Body"`
+	}
+	do(t, router, http.MethodGet, "/api/v1/prompt-templates/outreach?organization_id="+testOrganizationID, nil, http.StatusOK, &template)
+	if template.Version != 2 || !strings.Contains(template.Body, "second") {
+		t.Errorf("expected version 2 to be the latest, got %+v", template)
+	}
+	do(t, router, http.MethodGet, "/api/v1/prompt-templates/outreach?version=1&organization_id="+testOrganizationID, nil, http.StatusOK, &template)
+	if template.Version != 1 || !strings.Contains(template.Body, "first") {
+		t.Errorf("expected version 1, got %+v", template)
+	}
+	do(t, router, http.MethodGet, "/api/v1/prompt-templates/outreach?version=3&organization_id="+testOrganizationID, nil, http.StatusNotFound, nil)
+
+	var listed struct {
+		Templates []json.RawMessage `json:"templates"`
+		Builtin   []json.RawMessage `json:"builtin"`
+	}
+	do(t, router, http.MethodGet, "/api/v1/prompt-templates?organization_id="+testOrganizationID, nil, http.StatusOK, &listed)
+	if len(listed.Templates) != 2 || len(listed.Builtin) == 0 {
+		t.Errorf("expected 2 stored and the built-in templates, got %d and %d", len(listed.Templates), len(listed.Builtin))
+	}
+
+	request := generationRequest()
+	request["prompt_template"] = "outreach@1"
+	var generated struct {
+		PromptTemplate string `json:"prompt_template"`
+		PromptVersion  int    `json:"prompt_version"`
+	}
+	do(t, router, http.MethodPost, "/api/v1/ai-responses", request, http.StatusCreated, &generated)
+	if generated.PromptTemplate != "outreach" || generated.PromptVersion != 1 {
+		t.Errorf("expected outreach@1 to be used, got %+v", generated)
+	}
+	if requests := provider.Requests(); len(requests) != 1 || !strings.Contains(requests[0].Prompt, "first email messages to Jane Doe") {
+		t.Errorf("expected the prompt to be rendered from version 1, got %d requests", len(requests))
+	}
+}
+
+func TestRouterReportsUsageAndExportsFeedback(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	var generated struct {
+		ID          string `json:"id"`
+		TotalTokens int64  `json:"total_tokens"`
+	}
+	do(t, router, http.MethodPost, "/api/v1/ai-responses", generationRequest(), http.StatusCreated, &generated)
+
+	var usage struct {
+		Daily struct {
+			Used int64 `json:"used"`
+		} `json:"daily"`
+	}
+	do(t, router, http.MethodGet, "/api/v1/usage/"+testOrganizationID, nil, http.StatusOK, &usage)
+	if usage.Daily.Used == 0 {
+		t.Errorf("expected the generation to count against the daily quota, got %+v", usage)
+	}
+
+	var rows []struct {
+		Model       string `json:"model"`
+		Calls       int64  `json:"calls"`
+		TotalTokens int64  `json:"total_tokens"`
+	}
+	do(t, router, http.MethodGet, "/api/v1/usage-reports?group_by=model&organization_id="+testOrganizationID, nil, http.StatusOK, &rows)
+	if len(rows) != 1 || rows[0].Model == "" || rows[0].Calls != 1 || rows[0].TotalTokens != usage.Daily.Used {
+		t.Errorf("expected one model row with %d tokens, got %+v", usage.Daily.Used, rows)
+	}
+	do(t, router, http.MethodGet, "/api/v1/usage-reports?group_by=bogus", nil, http.StatusBadRequest, nil)
+
+	feedbackPath := "/api/v1/ai-responses/" + testOrganizationID + "/" + generated.ID + "/feedback"
+	do(t, router, http.MethodPost, feedbackPath, gin.H{"rating": 5, "selected_index": 0}, http.StatusCreated, nil)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/exports/fine-tuning?organization_id="+testOrganizationID, nil)
+	request.Header.Set("X-API-Key", testAdminKey)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	lines := strings.Split(strings.TrimSpace(recorder.Body.String()), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], "Hi Jane, quick demo?") {
+		t.Errorf("expected one example with the selected message, got %q", recorder.Body.String())
+	}
+}
+
+func TestRouterRunsGenerationJobs(t *testing.T) {
+	server, _ := newTestServer(t)
+	router := SetupRouter(server)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pool := jobs.NewPool(server.Providers, server.Repositories, &config.JobConfig{
+		Workers:      1,
+		PollInterval: 10 * time.Millisecond,
+		MaxAttempts:  3,
+		RetryBackoff: 10 * time.Millisecond,
+		MaxBackoff:   10 * time.Millisecond,
+		StaleAfter:   time.Minute,
+	})
+	pool.Start(ctx)
+	defer pool.Wait()
+	defer cancel()
+
+	var job struct {
+		ID string `json:"ID"`
+	}
+	do(t, router, http.MethodPost, "/api/v1/jobs", generationRequest(), http.StatusAccepted, &job)
+
+	var status struct {
+		Job struct {
+			Status string `json:"Status"`
+		} `json:"job"`
+		Result *struct {
+			ID string `json:"ID"`
+		} `json:"result"`
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for status.Job.Status != models.JobStatusSucceeded && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		do(t, router, http.MethodGet, "/api/v1/jobs/"+job.ID, nil, http.StatusOK, &status)
+	}
+	if status.Job.Status != models.JobStatusSucceeded || status.Result == nil {
+		t.Fatalf("expected the job to succeed with a result, got %+v", status)
+	}
+	do(t, router, http.MethodGet, "/api/v1/jobs/1b0e6a3e-5f5c-4d8e-9a51-0c8f3f1d2b7a", nil, http.StatusNotFound, nil)
+}
+
+// failingJobs is a job repository whose lookups fail like an unreachable
+// database
+type failingJobs struct {
+	repositories.JobRepository
+}
+
+func (failingJobs) Get(id uuid.UUID) (models.GenerationJob, error) {
+	return models.GenerationJob{}, errors.New("failed to load job: connection refused")
+}
+
+func TestRouterTellsJobLookupFailuresFromMissingJobs(t *testing.T) {
+	server, _ := newTestServer(t)
+	router := SetupRouter(server)
+
+	var job struct {
+		ID string `json:"ID"`
+	}
+	do(t, router, http.MethodPost, "/api/v1/jobs", generationRequest(), http.StatusAccepted, &job)
+
+	const otherOrganizationID = "7d2e4b1a-8c3f-4e5d-a6b7-c8d9e0f1a2b3"
+	var issued struct {
+		Key string `json:"key"`
+	}
+	do(t, router, http.MethodPost, "/api/v1/api-keys", gin.H{"organization_id": otherOrganizationID, "name": "ci"}, http.StatusCreated, &issued)
+	doAs(t, router, issued.Key, http.MethodGet, "/api/v1/jobs/"+job.ID, nil, http.StatusNotFound, nil)
+
+	server.Repositories.Jobs = failingJobs{server.Repositories.Jobs}
+	do(t, router, http.MethodGet, "/api/v1/jobs/"+job.ID, nil, http.StatusInternalServerError, nil)
+}
+
+func TestRouterExtractsAndSavesBusinessInfo(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>MobiloCard</title></head><body><p>MobiloCard Pro makes teams efficient.</p></body></html>`))
+	}))
+	defer site.Close()
+
+	server, provider := newTestServer(t)
+	server.CrawlClient = site.Client()
+	provider.Content = `{"company_name":"MobiloCard","industry":"Tech","core_products":["MobiloCard Pro"],"value_props":["Increase efficiency"]}`
+	router := SetupRouter(server)
+
+	var extracted struct {
+		BusinessInfo struct {
+			CompanyName string `json:"company_name"`
+		} `json:"business_info"`
+		Saved bool `json:"saved"`
+	}
+	do(t, router, http.MethodPost, "/api/v1/business-info/extract", gin.H{
+		"organization_id": testOrganizationID,
+		"url":             site.URL,
+		"save":            true,
+	}, http.StatusOK, &extracted)
+	if extracted.BusinessInfo.CompanyName != "MobiloCard" || !extracted.Saved {
+		t.Fatalf("expected the extraction to be saved, got %+v", extracted)
+	}
+
+	var setting struct {
+		Value string `json:"Value"`
+	}
+	do(t, router, http.MethodGet, "/api/v1/settings/"+testOrganizationID+"/business_info", nil, http.StatusOK, &setting)
+	if !strings.Contains(setting.Value, "MobiloCard Pro") {
+		t.Errorf("expected the business info setting to be stored, got %q", setting.Value)
+	}
+
+	var rows []struct {
+		Operation string `json:"operation"`
+	}
+	do(t, router, http.MethodGet, "/api/v1/usage-reports?group_by=operation&organization_id="+testOrganizationID, nil, http.StatusOK, &rows)
+	if len(rows) != 1 || rows[0].Operation != models.UsageOperationBusinessInfo {
+		t.Errorf("expected the extraction to be recorded as usage, got %+v", rows)
+	}
+}
+
+func TestRouterGeneratesFromLinkedInProfile(t *testing.T) {
+	router, provider := newTestRouter(t)
+
+	profile, err := os.ReadFile(filepath.Join("..", "tools", "linkedin-tool", "testdata", "full_profile.json"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	request := gin.H{
+		"organization_id":   testOrganizationID,
+		"channel":           "linkedin",
+		"business_info":     gin.H{"company_name": "MobiloCard", "industry": "Tech", "core_products": []string{"MobiloCard Pro"}, "value_props": []string{"Speed"}},
+		"goal":              gin.H{"type": "sales", "description": "Book product demo", "target_outcome": "Schedule a call"},
+		"customer_industry": "Software",
+		"linkedin_profile":  json.RawMessage(profile),
+	}
+
+	var generated struct {
+		Input struct {
+			CustomerProfile struct {
+				Name      string   `json:"name"`
+				Title     string   `json:"title"`
+				Company   string   `json:"company"`
+				Industry  string   `json:"industry"`
+				Interests []string `json:"interests"`
+			} `json:"customer_profile"`
+		} `json:"input"`
+	}
+	do(t, router, http.MethodPost, "/api/v1/ai-responses/linkedin", request, http.StatusCreated, &generated)
+	customer := generated.Input.CustomerProfile
+	if customer.Name != "Jane Roe" || customer.Title != "VP Engineering" || customer.Company != "Acme" || customer.Industry != "Software" || len(customer.Interests) == 0 {
+		t.Errorf("expected the customer profile to be derived from the LinkedIn profile, got %+v", customer)
+	}
+	requests := provider.Requests()
+	if len(requests) != 1 || !strings.Contains(requests[0].Prompt, "Engineering leader building data platforms.") {
+		t.Errorf("expected the profile background in the prompt, got %d requests", len(requests))
+	}
+
+	request["linkedin_profile"] = json.RawMessage(`"not a profile"`)
+	do(t, router, http.MethodPost, "/api/v1/ai-responses/linkedin", request, http.StatusBadRequest, nil)
+	request["linkedin_profile"] = json.RawMessage(`{"position":"CTO"}`)
+	do(t, router, http.MethodPost, "/api/v1/ai-responses/linkedin", request, http.StatusBadRequest, nil)
+	if len(provider.Requests()) != 1 {
+		t.Errorf("expected invalid profiles to never reach the provider")
+	}
+}
+
+func TestRouterRejectsZeroLinkedInLimits(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	for _, key := range []string{"linkedin.max_experience", "linkedin.max_education", "linkedin.max_activity", "linkedin.max_posts"} {
+		do(t, router, http.MethodPost, "/api/v1/settings", gin.H{
+			"organization_id": testOrganizationID,
+			"settings":        []gin.H{{"key": key, "value": "0"}},
+		}, http.StatusBadRequest, nil)
+	}
+	do(t, router, http.MethodPost, "/api/v1/settings", gin.H{
+		"organization_id": testOrganizationID,
+		"settings":        []gin.H{{"key": "linkedin.max_posts", "value": "1"}},
+	}, http.StatusCreated, nil)
+}
+
+func TestRouterRefusesToSaveIncompleteBusinessInfo(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><p>We make things.</p></body></html>`))
+	}))
+	defer site.Close()
+
+	server, provider := newTestServer(t)
+	server.CrawlClient = site.Client()
+	provider.Content = `{"company_name":"","industry":"Tech","core_products":[],"value_props":[]}`
+	router := SetupRouter(server)
+
+	extract := gin.H{"organization_id": testOrganizationID, "url": site.URL}
+	do(t, router, http.MethodPost, "/api/v1/business-info/extract", extract, http.StatusOK, nil)
+
+	extract["save"] = true
+	var rejected struct {
+		BusinessInfo struct {
+			Industry string `json:"industry"`
+		} `json:"business_info"`
+	}
+	do(t, router, http.MethodPost, "/api/v1/business-info/extract", extract, http.StatusUnprocessableEntity, &rejected)
+	if rejected.BusinessInfo.Industry != "Tech" {
+		t.Errorf("expected the incomplete extraction to be returned, got %+v", rejected)
+	}
+	do(t, router, http.MethodGet, "/api/v1/settings/"+testOrganizationID+"/business_info", nil, http.StatusNotFound, nil)
+
+	// The settings API holds stored business info to the same rules
+	for _, value := range []string{`{}`, `{"company_name":"","industry":"Tech","core_products":["Widgets"],"value_props":["Fast"]}`} {
+		do(t, router, http.MethodPost, "/api/v1/settings", gin.H{
+			"organization_id": testOrganizationID,
+			"settings":        []gin.H{{"key": "business_info", "value": value}},
+		}, http.StatusBadRequest, nil)
+	}
+	do(t, router, http.MethodPost, "/api/v1/settings", gin.H{
+		"organization_id": testOrganizationID,
+		"settings": []gin.H{{"key": "business_info",
+			"value": `{"company_name":"Acme","industry":"Tech","core_products":["Widgets"],"value_props":["Fast"]}`}},
+	}, http.StatusCreated, nil)
+	do(t, router, http.MethodPut, "/api/v1/settings/"+testOrganizationID, gin.H{"key": "business_info", "value": `{"company_name":"Acme"}`}, http.StatusBadRequest, nil)
+}
+
+func TestRouterReplacesSettingsWrittenTwice(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	for _, model := range []string{"gpt-4o-mini", "gpt-4o"} {
+		do(t, router, http.MethodPost, "/api/v1/settings", gin.H{
+			"organization_id": testOrganizationID,
+			"settings":        []gin.H{{"key": "ai.model", "value": model}},
+		}, http.StatusCreated, nil)
+	}
+
+	var settings []struct {
+		Key   string `json:"Key"`
+		Value string `json:"Value"`
+	}
+	do(t, router, http.MethodGet, "/api/v1/settings/"+testOrganizationID, nil, http.StatusOK, &settings)
+	if len(settings) != 1 || settings[0].Value != "gpt-4o" {
+		t.Errorf("expected one ai.model setting with the latest value, got %+v", settings)
+	}
+}
+
+func TestRouterOnlyAcceptsAllowedModelEndpoints(t *testing.T) {
+	router, _ := newTestRouter(t)
+	t.Setenv("OPENAI_BASE_URL", "http://llm.internal/v1")
+	t.Setenv("AI_ALLOWED_BASE_URLS", "https://api.openai.com/v1")
+
+	setting := func(key string, value string, wantStatus int) {
+		t.Helper()
+		do(t, router, http.MethodPost, "/api/v1/settings", gin.H{
+			"organization_id": testOrganizationID,
+			"settings":        []gin.H{{"key": key, "value": value}},
+		}, wantStatus, nil)
+	}
+	setting("ai.base_url", "http://169.254.169.254/latest", http.StatusBadRequest)
+	setting("ai.fallbacks", "gpt-4o-mini=http://10.0.0.1/v1", http.StatusBadRequest)
+	setting("ai.base_url", "https://api.openai.com/v1", http.StatusCreated)
+	setting("ai.fallbacks", "llama3.1,gpt-4o-mini=https://api.openai.com/v1", http.StatusCreated)
+}
+
+func TestRouterOnlyLetsTheAdminEnableDebugLogging(t *testing.T) {
+	router, _ := newTestRouter(t)
+	// Its own organization keeps tenant requests out of the shared rate limit
+	const organizationID = "0b7c8e7a-5f2d-4c1e-9d7a-3e2f1a6b9c44"
+
+	var issued struct {
+		Key string `json:"key"`
+	}
+	do(t, router, http.MethodPost, "/api/v1/api-keys", gin.H{"organization_id": organizationID, "name": "ci"}, http.StatusCreated, &issued)
+
+	debug := gin.H{"organization_id": organizationID, "settings": []gin.H{{"key": "logging.debug", "value": "true"}}}
+	doAs(t, router, issued.Key, http.MethodPost, "/api/v1/settings", debug, http.StatusForbidden, nil)
+	do(t, router, http.MethodPost, "/api/v1/settings", debug, http.StatusCreated, nil)
+	doAs(t, router, issued.Key, http.MethodPut, "/api/v1/settings/"+organizationID, gin.H{"key": "logging.debug", "value": "false"}, http.StatusForbidden, nil)
+}
+
+func TestRouterEnforcesLimitsSetByTheAdmin(t *testing.T) {
+	server, _ := newTestServer(t)
+	t.Setenv("QUOTA_DAILY_TOKENS", "0")
+	t.Setenv("QUOTA_MONTHLY_TOKENS", "0")
+	router := SetupRouter(server)
+
+	var issued struct {
+		Key string `json:"key"`
+	}
+	do(t, router, http.MethodPost, "/api/v1/api-keys", gin.H{"organization_id": testOrganizationID, "name": "ci"}, http.StatusCreated, &issued)
+
+	for _, key := range []string{"rate_limit.requests_per_minute", "rate_limit.burst", "quota.daily_tokens", "quota.monthly_tokens"} {
+		doAs(t, router, issued.Key, http.MethodPost, "/api/v1/settings", gin.H{
+			"organization_id": testOrganizationID,
+			"settings":        []gin.H{{"key": key, "value": "100000"}},
+		}, http.StatusForbidden, nil)
+	}
+	// A later quota replaces the earlier one instead of sitting next to it
+	for _, quota := range []string{"100000", "1"} {
+		do(t, router, http.MethodPost, "/api/v1/settings", gin.H{
+			"organization_id": testOrganizationID,
+			"settings":        []gin.H{{"key": "quota.daily_tokens", "value": quota}},
+		}, http.StatusCreated, nil)
+	}
+	var quota struct {
+		Value string `json:"Value"`
+	}
+	do(t, router, http.MethodGet, "/api/v1/settings/"+testOrganizationID+"/quota.daily_tokens", nil, http.StatusOK, &quota)
+	if quota.Value != "1" {
+		t.Errorf("expected the latest daily quota, got %q", quota.Value)
+	}
+	doAs(t, router, issued.Key, http.MethodPut, "/api/v1/settings/"+testOrganizationID, gin.H{"key": "quota.daily_tokens", "value": "100000"}, http.StatusForbidden, nil)
+
+	request := generationRequest()
+	profile := request["customer_profile"]
+	delete(request, "customer_profile")
+	request["customer_profiles"] = []interface{}{profile, profile, profile}
+	request["concurrency"] = 1
+	var batch struct {
+		Succeeded int `json:"succeeded"`
+		Results   []struct {
+			Error string `json:"error"`
+		} `json:"results"`
+	}
+	doAs(t, router, issued.Key, http.MethodPost, "/api/v1/ai-responses/batch", request, http.StatusOK, &batch)
+	if batch.Succeeded != 1 || !strings.Contains(batch.Results[2].Error, "token quota exceeded") {
+		t.Errorf("expected the batch to stop once the quota was used up, got %+v", batch)
+	}
+	doAs(t, router, issued.Key, http.MethodPost, "/api/v1/ai-responses", generationRequest(), http.StatusTooManyRequests, nil)
+
+	// Jobs enqueued by the admin key are postponed until the quota resets
+	ctx, cancel := context.WithCancel(context.Background())
+	pool := jobs.NewPool(server.Providers, server.Repositories, &config.JobConfig{
+		Workers:      1,
+		PollInterval: 10 * time.Millisecond,
+		MaxAttempts:  3,
+		RetryBackoff: 10 * time.Millisecond,
+		MaxBackoff:   10 * time.Millisecond,
+		StaleAfter:   time.Minute,
+	})
+	pool.Start(ctx)
+	defer pool.Wait()
+	defer cancel()
+
+	var job struct {
+		ID string `json:"ID"`
+	}
+	do(t, router, http.MethodPost, "/api/v1/jobs", generationRequest(), http.StatusAccepted, &job)
+
+	var status struct {
+		Job struct {
+			Status    string    `json:"Status"`
+			Attempts  int       `json:"Attempts"`
+			RunAt     time.Time `json:"RunAt"`
+			LastError string    `json:"LastError"`
+		} `json:"job"`
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for status.Job.LastError == "" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		do(t, router, http.MethodGet, "/api/v1/jobs/"+job.ID, nil, http.StatusOK, &status)
+	}
+	resetsAt := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	if status.Job.Status != models.JobStatusPending || status.Job.Attempts != 0 || !status.Job.RunAt.Equal(resetsAt) {
+		t.Errorf("expected the job to be postponed without using an attempt, got %+v", status.Job)
+	}
+}
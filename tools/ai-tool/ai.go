@@ -1 +1,40 @@
 package aiTool
+
+import (
+	"context"
+)
+
+// Provider generates structured completions from a language model.
+type Provider interface {
+	// Complete sends the prompt to the model and returns the raw content,
+	// which is expected to match request.Schema when one is given.
+	Complete(ctx context.Context, request CompletionRequest) (CompletionResponse, error)
+	// Stream behaves like Complete but calls onDelta with each chunk of
+	// content as it arrives. Returning an error from onDelta aborts the call.
+	Stream(ctx context.Context, request CompletionRequest, onDelta func(delta string) error) (CompletionResponse, error)
+	// Model returns the name of the model the provider talks to.
+	Model() string
+}
+
+type CompletionRequest struct {
+	Prompt     string
+	SchemaName string
+	Schema     interface{}
+	// Model overrides the provider's default model when set
+	Model string
+	// Temperature and MaxTokens are left to the model's defaults when unset
+	Temperature *float64
+	MaxTokens   int64
+}
+
+type Usage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
+type CompletionResponse struct {
+	Content string
+	Model   string
+	Usage   Usage
+}
@@ -0,0 +1,141 @@
+package aiTool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// ErrCircuitOpen is returned without calling the model while its endpoint's
+// circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitBreaker wraps a provider and fails calls fast once the endpoint
+// looks down. After threshold consecutive failures the breaker opens; once
+// cooldown has passed it lets a single probe call through (half open),
+// which closes the breaker when it succeeds and opens it again when it
+// fails. Only retryable errors count as failures: a rejected request means
+// the endpoint is up.
+type CircuitBreaker struct {
+	provider  Provider
+	name      string
+	threshold int
+	cooldown  time.Duration
+	// now is the breaker's clock, replaced in tests
+	now func() time.Time
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewCircuitBreaker(provider Provider, name string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		provider:  provider,
+		name:      name,
+		threshold: max(threshold, 1),
+		cooldown:  cooldown,
+		now:       time.Now,
+		state:     BreakerClosed,
+	}
+}
+
+func (b *CircuitBreaker) Model() string {
+	return b.provider.Model()
+}
+
+func (b *CircuitBreaker) Complete(ctx context.Context, request CompletionRequest) (CompletionResponse, error) {
+	if err := b.allow(); err != nil {
+		return CompletionResponse{}, err
+	}
+	response, err := b.provider.Complete(ctx, request)
+	b.record(err)
+	return response, err
+}
+
+func (b *CircuitBreaker) Stream(ctx context.Context, request CompletionRequest, onDelta func(delta string) error) (CompletionResponse, error) {
+	if err := b.allow(); err != nil {
+		return CompletionResponse{}, err
+	}
+	var callbackErr error
+	response, err := b.provider.Stream(ctx, request, func(delta string) error {
+		callbackErr = onDelta(delta)
+		return callbackErr
+	})
+	if callbackErr != nil {
+		// The caller aborted the stream; the endpoint was answering
+		b.record(nil)
+	} else {
+		b.record(err)
+	}
+	return response, err
+}
+
+// State returns the current state of the breaker
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.currentState(b.now())
+}
+
+func (b *CircuitBreaker) currentState(now time.Time) string {
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= b.cooldown {
+		b.state = BreakerHalfOpen
+	}
+	return b.state
+}
+
+// allow reports ErrCircuitOpen unless a call may go through
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.currentState(b.now()) {
+	case BreakerOpen:
+		return fmt.Errorf("%w for %s", ErrCircuitOpen, b.name)
+	case BreakerHalfOpen:
+		if b.probing {
+			return fmt.Errorf("%w for %s", ErrCircuitOpen, b.name)
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// record updates the breaker with the outcome of a call
+func (b *CircuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	halfOpen := b.state == BreakerHalfOpen
+	b.probing = false
+	switch {
+	case errors.Is(err, context.Canceled):
+		// The caller gave up, which says nothing about the endpoint
+	case err == nil || !IsRetryable(err):
+		if b.state != BreakerClosed {
+			slog.Info("Circuit breaker closed", "endpoint", b.name)
+		}
+		b.state = BreakerClosed
+		b.failures = 0
+	default:
+		b.failures++
+		if halfOpen || (b.state == BreakerClosed && b.failures >= b.threshold) {
+			slog.Warn("Circuit breaker opened", "endpoint", b.name, "failures", b.failures, "error", err)
+			b.state = BreakerOpen
+			b.openedAt = b.now()
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package aiTool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyProvider fails with err until it is cleared, and holds every call
+// while hold is set
+type flakyProvider struct {
+	mu    sync.Mutex
+	err   error
+	calls int
+	hold  chan struct{}
+}
+
+func (p *flakyProvider) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+func (p *flakyProvider) callCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+func (p *flakyProvider) Model() string {
+	return "flaky"
+}
+
+func (p *flakyProvider) Complete(ctx context.Context, request CompletionRequest) (CompletionResponse, error) {
+	p.mu.Lock()
+	p.calls++
+	err, hold := p.err, p.hold
+	p.mu.Unlock()
+
+	if hold != nil {
+		<-hold
+	}
+	if err != nil {
+		return CompletionResponse{}, err
+	}
+	return CompletionResponse{Content: "ok", Model: "flaky"}, nil
+}
+
+func (p *flakyProvider) Stream(ctx context.Context, request CompletionRequest, onDelta func(delta string) error) (CompletionResponse, error) {
+	response, err := p.Complete(ctx, request)
+	if err != nil {
+		return CompletionResponse{}, err
+	}
+	if err := onDelta(response.Content); err != nil {
+		return CompletionResponse{}, err
+	}
+	return response, nil
+}
+
+// testClock is a manually advanced clock
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+var errUnavailable = errors.New("service unavailable")
+
+func newTestBreaker(threshold int, cooldown time.Duration) (*CircuitBreaker, *flakyProvider, *testClock) {
+	provider := &flakyProvider{}
+	clock := &testClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	breaker := NewCircuitBreaker(provider, "http://llm.internal/v1", threshold, cooldown)
+	breaker.now = clock.Now
+	return breaker, provider, clock
+}
+
+func complete(breaker *CircuitBreaker) error {
+	_, err := breaker.Complete(context.Background(), CompletionRequest{Prompt: "hi"})
+	return err
+}
+
+func TestCircuitBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	breaker, provider, _ := newTestBreaker(3, time.Minute)
+
+	provider.fail(errUnavailable)
+	complete(breaker)
+	complete(breaker)
+	provider.fail(nil)
+	complete(breaker)
+	provider.fail(errUnavailable)
+	complete(breaker)
+	complete(breaker)
+	if state := breaker.State(); state != BreakerClosed {
+		t.Fatalf("expected a success to reset the failure count, got %s", state)
+	}
+
+	if err := complete(breaker); !errors.Is(err, errUnavailable) {
+		t.Fatalf("expected the provider error, got %v", err)
+	}
+	if state := breaker.State(); state != BreakerOpen {
+		t.Fatalf("expected the third consecutive failure to open the breaker, got %s", state)
+	}
+
+	calls := provider.callCount()
+	err := complete(breaker)
+	if !errors.Is(err, ErrCircuitOpen) || IsRetryable(err) {
+		t.Errorf("expected a non-retryable ErrCircuitOpen, got %v", err)
+	}
+	if provider.callCount() != calls {
+		t.Errorf("expected an open breaker not to call the provider")
+	}
+}
+
+func TestCircuitBreakerIgnoresRejectionsAndCancellations(t *testing.T) {
+	breaker, provider, _ := newTestBreaker(1, time.Minute)
+
+	for _, err := range []error{ErrEndpointNotAllowed, context.Canceled} {
+		provider.fail(err)
+		complete(breaker)
+		if state := breaker.State(); state != BreakerClosed {
+			t.Errorf("expected %v not to count as a failure, got %s", err, state)
+		}
+	}
+
+	provider.fail(nil)
+	_, err := breaker.Stream(context.Background(), CompletionRequest{Prompt: "hi"}, func(string) error {
+		return errors.New("client went away")
+	})
+	if err == nil || breaker.State() != BreakerClosed {
+		t.Errorf("expected an aborted stream to leave the breaker closed, got %v and %s", err, breaker.State())
+	}
+}
+
+func TestCircuitBreakerProbesAfterCooldown(t *testing.T) {
+	breaker, provider, clock := newTestBreaker(1, time.Minute)
+
+	provider.fail(errUnavailable)
+	complete(breaker)
+	clock.Advance(time.Minute - time.Second)
+	if state := breaker.State(); state != BreakerOpen {
+		t.Fatalf("expected the breaker to stay open during the cooldown, got %s", state)
+	}
+	clock.Advance(time.Second)
+	if state := breaker.State(); state != BreakerHalfOpen {
+		t.Fatalf("expected the breaker to be half open after the cooldown, got %s", state)
+	}
+
+	if err := complete(breaker); !errors.Is(err, errUnavailable) {
+		t.Fatalf("expected the probe to reach the provider, got %v", err)
+	}
+	if state := breaker.State(); state != BreakerOpen {
+		t.Fatalf("expected a failed probe to open the breaker again, got %s", state)
+	}
+	clock.Advance(time.Minute - time.Second)
+	if err := complete(breaker); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected a failed probe to restart the cooldown, got %v", err)
+	}
+
+	clock.Advance(time.Second)
+	provider.fail(nil)
+	if err := complete(breaker); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state := breaker.State(); state != BreakerClosed {
+		t.Errorf("expected a successful probe to close the breaker, got %s", state)
+	}
+}
+
+func TestCircuitBreakerLetsASingleProbeThrough(t *testing.T) {
+	breaker, provider, clock := newTestBreaker(1, time.Minute)
+
+	provider.fail(errUnavailable)
+	complete(breaker)
+	clock.Advance(time.Minute)
+	provider.fail(nil)
+
+	hold := make(chan struct{})
+	provider.mu.Lock()
+	provider.hold = hold
+	provider.mu.Unlock()
+
+	probed := make(chan error)
+	go func() {
+		probed <- complete(breaker)
+	}()
+	for provider.callCount() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := complete(breaker); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("expected calls during the probe to fail fast, got %v", err)
+		}
+	}
+	if provider.callCount() != 2 {
+		t.Errorf("expected only the probe to reach the provider, got %d calls", provider.callCount())
+	}
+
+	close(hold)
+	if err := <-probed; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := complete(breaker); err != nil || breaker.State() != BreakerClosed {
+		t.Errorf("expected the breaker to close after the probe, got %v and %s", err, breaker.State())
+	}
+}
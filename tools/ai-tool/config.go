@@ -1 +1,158 @@
 package aiTool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-server/config"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	ProviderOpenAI = "openai"
+	ProviderFake   = "fake"
+)
+
+// NewProvider builds the provider selected by the AI configuration.
+func NewProvider(aiConfig *config.AIConfig) (Provider, error) {
+	switch aiConfig.Provider {
+	case ProviderOpenAI, "":
+		return NewOpenAIProvider(aiConfig.BaseURL, aiConfig.APIKey, aiConfig.Model), nil
+	case ProviderFake:
+		return NewFakeProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown AI provider: %s", aiConfig.Provider)
+	}
+}
+
+// ErrEndpointNotAllowed is returned for calls to an endpoint the operator
+// has not configured
+var ErrEndpointNotAllowed = errors.New("model endpoint not allowed")
+
+// Resolver hands out the provider for an OpenAI-compatible endpoint,
+// creating and reusing one provider per base URL. An empty base URL or the
+// configured one returns the default provider. Endpoints not allowed by the
+// AI configuration get a provider that fails every call, so the API key is
+// never sent to them.
+type Resolver struct {
+	config          *config.AIConfig
+	defaultProvider Provider
+
+	mu        sync.Mutex
+	providers map[string]Provider
+}
+
+// EndpointStatus is the circuit breaker state of an endpoint
+type EndpointStatus struct {
+	BaseURL string `json:"base_url"`
+	State   string `json:"state"`
+}
+
+// NewResolver creates the providers of aiConfig, each behind its own
+// circuit breaker unless AI_BREAKER_FAILURES is zero.
+func NewResolver(aiConfig *config.AIConfig) (*Resolver, error) {
+	provider, err := NewProvider(aiConfig)
+	if err != nil {
+		return nil, err
+	}
+	resolver := &Resolver{
+		config:    aiConfig,
+		providers: map[string]Provider{},
+	}
+	resolver.defaultProvider = resolver.withBreaker(provider, aiConfig.BaseURL)
+	return resolver, nil
+}
+
+// NewStaticResolver always returns provider, e.g. a FakeProvider in tests.
+func NewStaticResolver(provider Provider) *Resolver {
+	return &Resolver{defaultProvider: provider}
+}
+
+// Default returns the provider for the configured endpoint.
+func (r *Resolver) Default() Provider {
+	return r.defaultProvider
+}
+
+// Provider returns the provider for baseURL.
+func (r *Resolver) Provider(baseURL string) Provider {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if r.config == nil || baseURL == "" || baseURL == strings.TrimRight(r.config.BaseURL, "/") || r.config.Provider == ProviderFake {
+		return r.defaultProvider
+	}
+
+	if !r.config.AllowsBaseURL(baseURL) {
+		return deniedProvider{baseURL: baseURL, model: r.config.Model}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	provider, ok := r.providers[baseURL]
+	if !ok {
+		provider = r.withBreaker(NewOpenAIProvider(baseURL, r.config.APIKey, r.config.Model), baseURL)
+		r.providers[baseURL] = provider
+	}
+	return provider
+}
+
+func (r *Resolver) withBreaker(provider Provider, baseURL string) Provider {
+	if r.config.BreakerFailures <= 0 {
+		return provider
+	}
+	return NewCircuitBreaker(provider, baseURL, r.config.BreakerFailures, r.config.BreakerCooldown)
+}
+
+// Ready reports whether the default endpoint accepts calls, i.e. its
+// circuit breaker is not open
+func (r *Resolver) Ready() bool {
+	breaker, ok := r.defaultProvider.(*CircuitBreaker)
+	return !ok || breaker.State() != BreakerOpen
+}
+
+// Endpoints returns the breaker state of the default endpoint followed by
+// every other endpoint used so far. Endpoints without a breaker are
+// reported as closed.
+func (r *Resolver) Endpoints() []EndpointStatus {
+	baseURL := ""
+	if r.config != nil {
+		baseURL = r.config.BaseURL
+	}
+	endpoints := []EndpointStatus{{BaseURL: baseURL, State: providerState(r.defaultProvider)}}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for baseURL, provider := range r.providers {
+		endpoints = append(endpoints, EndpointStatus{BaseURL: baseURL, State: providerState(provider)})
+	}
+	sort.Slice(endpoints[1:], func(i, j int) bool {
+		return endpoints[i+1].BaseURL < endpoints[j+1].BaseURL
+	})
+	return endpoints
+}
+
+func providerState(provider Provider) string {
+	if breaker, ok := provider.(*CircuitBreaker); ok {
+		return breaker.State()
+	}
+	return BreakerClosed
+}
+
+// deniedProvider answers every call to an endpoint that is not allowed
+// with ErrEndpointNotAllowed
+type deniedProvider struct {
+	baseURL string
+	model   string
+}
+
+func (p deniedProvider) Model() string {
+	return p.model
+}
+
+func (p deniedProvider) Complete(ctx context.Context, request CompletionRequest) (CompletionResponse, error) {
+	return CompletionResponse{}, fmt.Errorf("%w: %s", ErrEndpointNotAllowed, p.baseURL)
+}
+
+func (p deniedProvider) Stream(ctx context.Context, request CompletionRequest, onDelta func(delta string) error) (CompletionResponse, error) {
+	return p.Complete(ctx, request)
+}
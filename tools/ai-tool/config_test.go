@@ -0,0 +1,39 @@
+package aiTool
+
+import (
+	"context"
+	"errors"
+	"go-server/config"
+	"testing"
+)
+
+func TestResolverOnlyCallsAllowedEndpoints(t *testing.T) {
+	resolver, err := NewResolver(&config.AIConfig{
+		Provider:        ProviderOpenAI,
+		BaseURL:         "http://llm.internal/v1",
+		APIKey:          "server-key",
+		Model:           "llama3.2",
+		AllowedBaseURLs: []string{"https://api.openai.com/v1"},
+		Fallbacks:       []config.ModelEndpoint{{Model: "llama3.1", BaseURL: "http://backup.internal/v1"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resolver.Provider("") != resolver.Default() || resolver.Provider("http://llm.internal/v1/") != resolver.Default() {
+		t.Errorf("expected the configured endpoint to use the default provider")
+	}
+	for _, baseURL := range []string{"https://api.openai.com/v1", "http://backup.internal/v1"} {
+		if _, ok := resolver.Provider(baseURL).(*OpenAIProvider); !ok {
+			t.Errorf("expected an OpenAI provider for the allowed endpoint %s", baseURL)
+		}
+	}
+
+	_, err = resolver.Provider("http://169.254.169.254/latest").Complete(context.Background(), CompletionRequest{Prompt: "hi"})
+	if !errors.Is(err, ErrEndpointNotAllowed) || IsRetryable(err) {
+		t.Errorf("expected a non-retryable ErrEndpointNotAllowed, got %v", err)
+	}
+	if len(resolver.Endpoints()) != 3 {
+		t.Errorf("expected denied endpoints not to be tracked, got %+v", resolver.Endpoints())
+	}
+}
@@ -0,0 +1,139 @@
+package aiTool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/invopop/jsonschema"
+)
+
+// FakeProvider is an in-process provider for tests and offline development.
+// Unless Content or Err is set it answers with a deterministic document
+// generated from the request schema.
+type FakeProvider struct {
+	Content string
+	Err     error
+
+	mu       sync.Mutex
+	requests []CompletionRequest
+}
+
+const fakeModel = "fake"
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{}
+}
+
+func (p *FakeProvider) Model() string {
+	return fakeModel
+}
+
+func (p *FakeProvider) Complete(ctx context.Context, request CompletionRequest) (CompletionResponse, error) {
+	p.mu.Lock()
+	p.requests = append(p.requests, request)
+	p.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return CompletionResponse{}, err
+	}
+	if p.Err != nil {
+		return CompletionResponse{}, p.Err
+	}
+
+	content := p.Content
+	if content == "" {
+		data, err := json.Marshal(fakeValue(request.Schema, "value"))
+		if err != nil {
+			return CompletionResponse{}, fmt.Errorf("failed to build fake response: %w", err)
+		}
+		content = string(data)
+	}
+
+	promptTokens := countTokens(request.Prompt)
+	completionTokens := countTokens(content)
+	model := request.Model
+	if model == "" {
+		model = fakeModel
+	}
+	return CompletionResponse{
+		Content: content,
+		Model:   model,
+		Usage: Usage{
+			PromptTokens:     promptTokens,
+			CompletionTokens: completionTokens,
+			TotalTokens:      promptTokens + completionTokens,
+		},
+	}, nil
+}
+
+// fakeChunkSize is the number of bytes FakeProvider.Stream sends per delta.
+const fakeChunkSize = 16
+
+func (p *FakeProvider) Stream(ctx context.Context, request CompletionRequest, onDelta func(delta string) error) (CompletionResponse, error) {
+	response, err := p.Complete(ctx, request)
+	if err != nil {
+		return CompletionResponse{}, err
+	}
+
+	content := response.Content
+	for start := 0; start < len(content); start += fakeChunkSize {
+		end := min(start+fakeChunkSize, len(content))
+		if err := onDelta(content[start:end]); err != nil {
+			return CompletionResponse{}, err
+		}
+	}
+	return response, nil
+}
+
+// Requests returns the requests the provider has received so far.
+func (p *FakeProvider) Requests() []CompletionRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]CompletionRequest(nil), p.requests...)
+}
+
+// countTokens approximates a token count by splitting on whitespace.
+func countTokens(text string) int64 {
+	return int64(len(strings.Fields(text)))
+}
+
+func fakeValue(schema interface{}, name string) interface{} {
+	s, ok := schema.(*jsonschema.Schema)
+	if !ok || s == nil {
+		return map[string]interface{}{}
+	}
+
+	if len(s.Enum) > 0 {
+		return s.Enum[0]
+	}
+
+	switch s.Type {
+	case "object":
+		object := map[string]interface{}{}
+		if s.Properties != nil {
+			for pair := s.Properties.Oldest(); pair != nil; pair = pair.Next() {
+				object[pair.Key] = fakeValue(pair.Value, pair.Key)
+			}
+		}
+		return object
+	case "array":
+		items := make([]interface{}, 3)
+		for i := range items {
+			items[i] = fakeValue(s.Items, fmt.Sprintf("%s %d", name, i+1))
+		}
+		return items
+	case "string":
+		return "fake " + name
+	case "integer":
+		return 7
+	case "number":
+		return 7.5
+	case "boolean":
+		return true
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,157 @@
+package aiTool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+// OpenAIProvider talks to any OpenAI-compatible chat completions endpoint
+// (OpenAI, Ollama, vLLM, ...).
+type OpenAIProvider struct {
+	client *openai.Client
+	model  string
+}
+
+func NewOpenAIProvider(baseURL, apiKey, model string) *OpenAIProvider {
+	return &OpenAIProvider{
+		client: openai.NewClient(
+			option.WithBaseURL(baseURL),
+			option.WithAPIKey(apiKey),
+			// Retries are left to the caller's retry policy
+			option.WithMaxRetries(0),
+		),
+		model: model,
+	}
+}
+
+// IsRetryable reports whether a failed call may succeed when repeated:
+// timeouts, rate limiting, server errors and transport failures are,
+// rejected requests, calls to endpoints that are not allowed and calls
+// refused by an open circuit breaker are not.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrEndpointNotAllowed) {
+		return false
+	}
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		status := apiErr.StatusCode
+		return status == http.StatusRequestTimeout || status == http.StatusConflict ||
+			status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+	}
+	return true
+}
+
+func (p *OpenAIProvider) Model() string {
+	return p.model
+}
+
+func (p *OpenAIProvider) modelFor(request CompletionRequest) string {
+	if request.Model != "" {
+		return request.Model
+	}
+	return p.model
+}
+
+func (p *OpenAIProvider) Complete(ctx context.Context, request CompletionRequest) (CompletionResponse, error) {
+	chat, err := p.client.Chat.Completions.New(ctx, p.buildParams(request))
+	if err != nil {
+		return CompletionResponse{}, err
+	}
+	if len(chat.Choices) == 0 {
+		return CompletionResponse{}, fmt.Errorf("empty response from model %s", p.modelFor(request))
+	}
+
+	response := CompletionResponse{
+		Content: chat.Choices[0].Message.Content,
+		Model:   chat.Model,
+		Usage: Usage{
+			PromptTokens:     chat.Usage.PromptTokens,
+			CompletionTokens: chat.Usage.CompletionTokens,
+			TotalTokens:      chat.Usage.TotalTokens,
+		},
+	}
+	if response.Model == "" {
+		response.Model = p.modelFor(request)
+	}
+	return response, nil
+}
+
+func (p *OpenAIProvider) Stream(ctx context.Context, request CompletionRequest, onDelta func(delta string) error) (CompletionResponse, error) {
+	params := p.buildParams(request)
+	params.StreamOptions = openai.F(openai.ChatCompletionStreamOptionsParam{
+		IncludeUsage: openai.F(true),
+	})
+
+	stream := p.client.Chat.Completions.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	accumulator := openai.ChatCompletionAccumulator{}
+	for stream.Next() {
+		chunk := stream.Current()
+		accumulator.AddChunk(chunk)
+
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			if err := onDelta(chunk.Choices[0].Delta.Content); err != nil {
+				return CompletionResponse{}, err
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return CompletionResponse{}, err
+	}
+	if len(accumulator.Choices) == 0 {
+		return CompletionResponse{}, fmt.Errorf("empty response from model %s", p.modelFor(request))
+	}
+
+	response := CompletionResponse{
+		Content: accumulator.Choices[0].Message.Content,
+		Model:   accumulator.Model,
+		Usage: Usage{
+			PromptTokens:     accumulator.Usage.PromptTokens,
+			CompletionTokens: accumulator.Usage.CompletionTokens,
+			TotalTokens:      accumulator.Usage.TotalTokens,
+		},
+	}
+	if response.Model == "" {
+		response.Model = p.modelFor(request)
+	}
+	return response, nil
+}
+
+func (p *OpenAIProvider) buildParams(request CompletionRequest) openai.ChatCompletionNewParams {
+	params := openai.ChatCompletionNewParams{
+		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{
+			openai.UserMessage(request.Prompt),
+		}),
+		Model: openai.F(p.modelFor(request)),
+	}
+	if request.Temperature != nil {
+		params.Temperature = openai.F(*request.Temperature)
+	}
+	if request.MaxTokens > 0 {
+		params.MaxTokens = openai.F(request.MaxTokens)
+	}
+
+	if request.Schema != nil {
+		schemaParam := openai.ResponseFormatJSONSchemaJSONSchemaParam{
+			Schema: openai.F(request.Schema),
+			Strict: openai.Bool(true),
+		}
+		if request.SchemaName != "" {
+			schemaParam.Name = openai.F(request.SchemaName)
+		}
+		params.ResponseFormat = openai.F[openai.ChatCompletionNewParamsResponseFormatUnion](
+			openai.ResponseFormatJSONSchemaParam{
+				Type:       openai.F(openai.ResponseFormatJSONSchemaTypeJSONSchema),
+				JSONSchema: openai.F(schemaParam),
+			},
+		)
+	}
+
+	return params
+}
@@ -1 +1,19 @@
 package linkedinTool
+
+// ParserOptions limits how many entries of each profile section are kept.
+// A limit of zero or less keeps every entry.
+type ParserOptions struct {
+	MaxExperience int
+	MaxEducation  int
+	MaxActivity   int
+	MaxPosts      int
+}
+
+func DefaultParserOptions() ParserOptions {
+	return ParserOptions{
+		MaxExperience: 3,
+		MaxEducation:  2,
+		MaxActivity:   5,
+		MaxPosts:      5,
+	}
+}
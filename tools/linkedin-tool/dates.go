@@ -0,0 +1,64 @@
+package linkedinTool
+
+import (
+	"strings"
+	"time"
+)
+
+// dateLayouts are the date formats seen in scraped LinkedIn profiles
+var dateLayouts = []string{
+	"Jan 2006",
+	"January 2006",
+	"2006-01-02",
+	"2006-01",
+	"01/2006",
+	"1/2006",
+	"2006",
+}
+
+// ParseProfileDate parses a LinkedIn start or end date. "Present" and empty
+// end dates mean the entry is current; ok is false for unparseable dates.
+func ParseProfileDate(value string, now time.Time) (date time.Time, current bool, ok bool) {
+	value = strings.TrimSpace(value)
+	switch strings.ToLower(value) {
+	case "present", "current", "now":
+		return now, true, true
+	case "":
+		return time.Time{}, false, false
+	}
+
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, false, true
+		}
+	}
+	return time.Time{}, false, false
+}
+
+// entryRecency returns the moment an entry was last active: its end date,
+// now when it is ongoing, or its start date when the end is unknown
+func entryRecency(startDate, endDate string, now time.Time) time.Time {
+	if end, _, ok := ParseProfileDate(endDate, now); ok {
+		return end
+	}
+	start, _, ok := ParseProfileDate(startDate, now)
+	if !ok {
+		return time.Time{}
+	}
+	if strings.TrimSpace(endDate) == "" {
+		// Started but never ended: treat as current
+		return now
+	}
+	return start
+}
+
+// moreRecent reports whether entry a should be listed before entry b
+func moreRecent(aStart, aEnd, bStart, bEnd string, now time.Time) bool {
+	aRecency, bRecency := entryRecency(aStart, aEnd, now), entryRecency(bStart, bEnd, now)
+	if !aRecency.Equal(bRecency) {
+		return aRecency.After(bRecency)
+	}
+	aStartDate, _, _ := ParseProfileDate(aStart, now)
+	bStartDate, _, _ := ParseProfileDate(bStart, now)
+	return aStartDate.After(bStartDate)
+}
@@ -0,0 +1,61 @@
+package linkedinTool
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// blockElements start a new line when converting HTML to text
+var blockElements = map[string]bool{
+	"br": true, "p": true, "div": true, "li": true, "ul": true, "ol": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// StripHTML converts an HTML fragment to plain text, keeping line breaks
+// for block elements and dropping scripts and styles.
+func StripHTML(fragment string) string {
+	tokenizer := html.NewTokenizer(strings.NewReader(fragment))
+
+	var builder strings.Builder
+	skip := 0
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return normalizeText(builder.String())
+		case html.TextToken:
+			if skip == 0 {
+				builder.Write(tokenizer.Text())
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			switch tag := string(name); {
+			case tag == "script" || tag == "style":
+				skip++
+			case blockElements[tag]:
+				builder.WriteString("\n")
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch tag := string(name); {
+			case tag == "script" || tag == "style":
+				if skip > 0 {
+					skip--
+				}
+			case blockElements[tag]:
+				builder.WriteString("\n")
+			}
+		}
+	}
+}
+
+// normalizeText collapses whitespace within lines and drops empty lines
+func normalizeText(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -3,7 +3,9 @@ package linkedinTool
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 )
 
 type Education struct {
@@ -75,10 +77,18 @@ type LinkedInProfile struct {
 	Education  []Education  `json:"education,omitempty"`
 }
 
+// ParseLinkedInDataForAI parses a scraped profile using DefaultParserOptions.
 func ParseLinkedInDataForAI(data []byte) (LinkedInProfile, error) {
+	return ParseLinkedInDataForAIWithOptions(data, DefaultParserOptions())
+}
+
+// ParseLinkedInDataForAIWithOptions parses a scraped profile, orders
+// experience from most to least recent, converts HTML descriptions to
+// text and trims each section to the configured limits.
+func ParseLinkedInDataForAIWithOptions(data []byte, options ParserOptions) (LinkedInProfile, error) {
 
-	if data == nil {
-		return LinkedInProfile{}, fmt.Errorf("data is nil")
+	if len(data) == 0 {
+		return LinkedInProfile{}, fmt.Errorf("data is empty")
 	}
 
 	// Unmarshal the JSON data into a struct
@@ -86,20 +96,65 @@ func ParseLinkedInDataForAI(data []byte) (LinkedInProfile, error) {
 
 	err := json.Unmarshal(data, &linkedinProfile)
 	if err != nil {
-		return LinkedInProfile{}, err
+		return LinkedInProfile{}, fmt.Errorf("invalid profile JSON: %w", err)
 	}
 
-	//  limit the experience to latest 3
-	linkedinProfile.Experience = linkedinProfile.Experience[:3] // TODO: make this dynamic
-
-	//  limit the education to latest 2
-	linkedinProfile.Education = linkedinProfile.Education[:2] // TODO: make this dynamic
+	now := time.Now()
 
-	//  run loop to remove "by {name}" from the interaction & limit to 5
-	linkedinProfile.Activity = linkedinProfile.Activity[:5] // TODO: make this dynamic
-	for i, activity := range linkedinProfile.Activity {
-		linkedinProfile.Activity[i].Interaction = strings.Replace(activity.Interaction, "by "+linkedinProfile.Name, "", 1)
+	for i := range linkedinProfile.Experience {
+		cleanExperience(&linkedinProfile.Experience[i], now)
+	}
+	sort.SliceStable(linkedinProfile.Experience, func(i, j int) bool {
+		a, b := linkedinProfile.Experience[i], linkedinProfile.Experience[j]
+		return moreRecent(a.StartDate, a.EndDate, b.StartDate, b.EndDate, now)
+	})
+
+	//  limit the experience, education, posts and activity to the latest entries
+	linkedinProfile.Experience = limit(linkedinProfile.Experience, options.MaxExperience)
+	linkedinProfile.Education = limit(linkedinProfile.Education, options.MaxEducation)
+	linkedinProfile.Posts = limit(linkedinProfile.Posts, options.MaxPosts)
+	linkedinProfile.Activity = limit(linkedinProfile.Activity, options.MaxActivity)
+
+	//  run loop to remove "by {name}" from the interaction
+	if linkedinProfile.Name != "" {
+		for i, activity := range linkedinProfile.Activity {
+			linkedinProfile.Activity[i].Interaction = strings.TrimSpace(strings.Replace(activity.Interaction, "by "+linkedinProfile.Name, "", 1))
+		}
 	}
 
 	return linkedinProfile, nil
 }
+
+// cleanExperience converts HTML descriptions to text and orders positions
+// from most to least recent
+func cleanExperience(experience *Experience, now time.Time) {
+	if experience.DescriptionHTML != nil {
+		text := StripHTML(*experience.DescriptionHTML)
+		experience.DescriptionHTML = &text
+		if experience.Description == nil || strings.TrimSpace(*experience.Description) == "" {
+			experience.Description = &text
+		}
+	}
+
+	for i := range experience.Positions {
+		position := &experience.Positions[i]
+		if position.DescriptionHTML != "" {
+			position.DescriptionHTML = StripHTML(position.DescriptionHTML)
+			if strings.TrimSpace(position.Description) == "" {
+				position.Description = position.DescriptionHTML
+			}
+		}
+	}
+	sort.SliceStable(experience.Positions, func(i, j int) bool {
+		a, b := experience.Positions[i], experience.Positions[j]
+		return moreRecent(a.StartDate, a.EndDate, b.StartDate, b.EndDate, now)
+	})
+}
+
+// limit returns at most size entries; size <= 0 keeps all of them
+func limit[T any](entries []T, size int) []T {
+	if size <= 0 || len(entries) <= size {
+		return entries
+	}
+	return entries[:size]
+}
@@ -0,0 +1,194 @@
+package linkedinTool
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func loadFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture %s: %v", name, err)
+	}
+	return data
+}
+
+func TestParseFullProfileAppliesDefaultLimits(t *testing.T) {
+	profile, err := ParseLinkedInDataForAI(loadFixture(t, "full_profile.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(profile.Experience) != 3 {
+		t.Errorf("expected 3 experiences, got %d", len(profile.Experience))
+	}
+	if len(profile.Education) != 2 {
+		t.Errorf("expected 2 education entries, got %d", len(profile.Education))
+	}
+	if len(profile.Activity) != 5 {
+		t.Errorf("expected 5 activities, got %d", len(profile.Activity))
+	}
+	if len(profile.Posts) != 5 {
+		t.Errorf("expected 5 posts, got %d", len(profile.Posts))
+	}
+}
+
+func TestParseFullProfileSortsExperienceByRecency(t *testing.T) {
+	profile, err := ParseLinkedInDataForAIWithOptions(loadFixture(t, "full_profile.json"), ParserOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"Acme", "Globex", "Initech", "Hooli"}
+	if len(profile.Experience) != len(want) {
+		t.Fatalf("expected %d experiences, got %d", len(want), len(profile.Experience))
+	}
+	for i, company := range want {
+		if profile.Experience[i].Company != company {
+			t.Errorf("experience %d: expected %s, got %s", i, company, profile.Experience[i].Company)
+		}
+	}
+
+	positions := profile.Experience[1].Positions
+	if len(positions) != 2 || positions[0].Title != "Director" {
+		t.Errorf("expected positions sorted most recent first, got %+v", positions)
+	}
+}
+
+func TestParseFullProfileStripsHTML(t *testing.T) {
+	profile, err := ParseLinkedInDataForAI(loadFixture(t, "full_profile.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	acme := profile.Experience[0]
+	want := "Leads platform & data.\n120 engineers\nFour sites"
+	if acme.DescriptionHTML == nil || *acme.DescriptionHTML != want {
+		t.Errorf("unexpected stripped HTML: %v", acme.DescriptionHTML)
+	}
+	if acme.Description == nil || *acme.Description != want {
+		t.Errorf("expected description to fall back to stripped HTML, got %v", acme.Description)
+	}
+
+	globex := profile.Experience[1]
+	if got := globex.Positions[1].Description; got != "Built the SRE team" {
+		t.Errorf("expected position description from HTML, got %q", got)
+	}
+}
+
+func TestParseFullProfileCleansInteractions(t *testing.T) {
+	profile, err := ParseLinkedInDataForAI(loadFixture(t, "full_profile.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := profile.Activity[0].Interaction; got != "Liked" {
+		t.Errorf("expected interaction without name, got %q", got)
+	}
+}
+
+func TestParseSparseProfileDoesNotPanic(t *testing.T) {
+	profile, err := ParseLinkedInDataForAI(loadFixture(t, "sparse_profile.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(profile.Experience) != 1 || len(profile.Activity) != 1 {
+		t.Errorf("expected sparse sections to be kept, got %d experiences and %d activities",
+			len(profile.Experience), len(profile.Activity))
+	}
+	if len(profile.Education) != 0 || len(profile.Posts) != 0 {
+		t.Errorf("expected missing sections to stay empty")
+	}
+}
+
+func TestParseEmptyProfile(t *testing.T) {
+	profile, err := ParseLinkedInDataForAI(loadFixture(t, "empty_profile.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if profile.Name != "" || len(profile.Experience) != 0 {
+		t.Errorf("expected empty profile, got %+v", profile)
+	}
+}
+
+func TestParseUnparseableDatesSortLast(t *testing.T) {
+	profile, err := ParseLinkedInDataForAIWithOptions(loadFixture(t, "unparseable_dates_profile.json"), ParserOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"Current Co", "Old Co", "Unknown Dates"}
+	for i, company := range want {
+		if profile.Experience[i].Company != company {
+			t.Errorf("experience %d: expected %s, got %s", i, company, profile.Experience[i].Company)
+		}
+	}
+}
+
+func TestParseCustomLimits(t *testing.T) {
+	options := ParserOptions{MaxExperience: 1, MaxEducation: 10, MaxActivity: 2, MaxPosts: 0}
+	profile, err := ParseLinkedInDataForAIWithOptions(loadFixture(t, "full_profile.json"), options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(profile.Experience) != 1 || profile.Experience[0].Company != "Acme" {
+		t.Errorf("expected only the latest experience, got %+v", profile.Experience)
+	}
+	if len(profile.Education) != 3 {
+		t.Errorf("expected all 3 education entries, got %d", len(profile.Education))
+	}
+	if len(profile.Activity) != 2 {
+		t.Errorf("expected 2 activities, got %d", len(profile.Activity))
+	}
+	if len(profile.Posts) != 6 {
+		t.Errorf("expected unlimited posts, got %d", len(profile.Posts))
+	}
+}
+
+func TestParseInvalidInput(t *testing.T) {
+	tests := map[string][]byte{
+		"nil":         nil,
+		"empty":       {},
+		"malformed":   loadFixture(t, "malformed_profile.json"),
+		"wrong types": loadFixture(t, "wrong_types_profile.json"),
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseLinkedInDataForAI(data); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestParseProfileDate(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value   string
+		want    time.Time
+		current bool
+		ok      bool
+	}{
+		{"Jan 2020", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), false, true},
+		{"September 2018", time.Date(2018, 9, 1, 0, 0, 0, 0, time.UTC), false, true},
+		{"2019", time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), false, true},
+		{"2021-03", time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), false, true},
+		{"Present", now, true, true},
+		{"", time.Time{}, false, false},
+		{"someday", time.Time{}, false, false},
+	}
+
+	for _, test := range tests {
+		got, current, ok := ParseProfileDate(test.value, now)
+		if !got.Equal(test.want) || current != test.current || ok != test.ok {
+			t.Errorf("ParseProfileDate(%q) = %v, %v, %v; want %v, %v, %v",
+				test.value, got, current, ok, test.want, test.current, test.ok)
+		}
+	}
+}
@@ -1 +1,62 @@
 package promptBuilderTool
+
+import (
+	_ "embed"
+)
+
+const (
+	DefaultTemplateName    = "default"
+	DefaultTemplateVersion = 3
+)
+
+//go:embed templates/default.v1.tmpl
+var defaultTemplateV1 string
+
+// defaultTemplateV2 asks for the configured number of variants instead of always 3
+//
+//go:embed templates/default.v2.tmpl
+var defaultTemplateV2 string
+
+// defaultTemplateV3 adds the organization's highly rated past messages as
+// examples
+//
+//go:embed templates/default.v3.tmpl
+var defaultTemplateV3 string
+
+// builtinTemplates ship with the server and are used when no stored
+// template matches
+var builtinTemplates = []Template{
+	{Name: DefaultTemplateName, Version: 1, Body: defaultTemplateV1},
+	{Name: DefaultTemplateName, Version: 2, Body: defaultTemplateV2},
+	{Name: DefaultTemplateName, Version: 3, Body: defaultTemplateV3},
+}
+
+// DefaultTemplate returns the latest built-in default template.
+func DefaultTemplate() Template {
+	template, _ := BuiltinTemplate(DefaultTemplateName, 0)
+	return template
+}
+
+// BuiltinTemplate looks up a built-in template. Version 0 returns the
+// latest version.
+func BuiltinTemplate(name string, version int) (Template, bool) {
+	var found Template
+	ok := false
+	for _, template := range builtinTemplates {
+		if template.Name != name {
+			continue
+		}
+		if version != 0 && template.Version == version {
+			return template, true
+		}
+		if version == 0 && (!ok || template.Version > found.Version) {
+			found, ok = template, true
+		}
+	}
+	return found, ok
+}
+
+// BuiltinTemplates lists every built-in template.
+func BuiltinTemplates() []Template {
+	return append([]Template(nil), builtinTemplates...)
+}
@@ -1 +1,71 @@
 package promptBuilderTool
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Template is a named, versioned text/template prompt. Versions are never
+// edited once created; changing the wording means adding a new version.
+type Template struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+	Body    string `json:"body"`
+}
+
+var funcs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	"add":   func(a, b int) int { return a + b },
+}
+
+// Parse compiles the template body. Referencing a field that does not
+// exist in the data is an error when the template is rendered.
+func (t Template) Parse() (*template.Template, error) {
+	parsed, err := template.New(t.String()).Funcs(funcs).Option("missingkey=error").Parse(t.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt template %s: %w", t, err)
+	}
+	return parsed, nil
+}
+
+// Render executes the template against data.
+func (t Template) Render(data interface{}) (string, error) {
+	parsed, err := t.Parse()
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	if err := parsed.Execute(&builder, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s: %w", t, err)
+	}
+	return builder.String(), nil
+}
+
+// String returns the template reference in "name@version" form.
+func (t Template) String() string {
+	return fmt.Sprintf("%s@%d", t.Name, t.Version)
+}
+
+// ParseRef splits a "name" or "name@version" reference. A missing version
+// is returned as 0, meaning the latest version.
+func ParseRef(ref string) (name string, version int, err error) {
+	name, rawVersion, found := strings.Cut(strings.TrimSpace(ref), "@")
+	if name == "" {
+		return "", 0, fmt.Errorf("invalid prompt template reference %q", ref)
+	}
+	if !found {
+		return name, 0, nil
+	}
+
+	version, err = strconv.Atoi(rawVersion)
+	if err != nil || version < 1 {
+		return "", 0, fmt.Errorf("invalid prompt template version in %q", ref)
+	}
+	return name, version, nil
+}
@@ -1 +1,41 @@
 package webcrawlTool
+
+import (
+	"net/http"
+	"time"
+)
+
+// Options bound how much of a site WebCrawl fetches.
+type Options struct {
+	// MaxDepth is how many links away from the start page to follow.
+	MaxDepth int
+	// MaxPages is the total number of pages to fetch, start page included.
+	MaxPages int
+	// MaxBytesPerPage caps how much of each response body is read.
+	MaxBytesPerPage int64
+	// MaxTextLength caps the extracted text per page, in characters.
+	MaxTextLength int
+	// Delay is the minimum pause between requests. A longer robots.txt
+	// Crawl-delay takes precedence, up to Delay plus MaxCrawlDelay.
+	Delay         time.Duration
+	MaxCrawlDelay time.Duration
+	Timeout       time.Duration
+	UserAgent     string
+	// HTTPClient is used for all requests; when nil, a NewPublicClient with
+	// Timeout is created so internal addresses cannot be crawled. Redirects
+	// off the site are never followed.
+	HTTPClient *http.Client
+}
+
+func DefaultOptions() Options {
+	return Options{
+		MaxDepth:        2,
+		MaxPages:        10,
+		MaxBytesPerPage: 2 << 20,
+		MaxTextLength:   20000,
+		Delay:           500 * time.Millisecond,
+		MaxCrawlDelay:   5 * time.Second,
+		Timeout:         15 * time.Second,
+		UserAgent:       "MobiloAIBot/1.0",
+	}
+}
@@ -0,0 +1,65 @@
+package webcrawlTool
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// sharedAddressSpace is the carrier-grade NAT range, which is as internal as
+// the private ranges
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicAddress reports whether ip may be crawled: loopback, private,
+// link-local (including the 169.254.169.254 metadata service), multicast and
+// unspecified addresses may not
+func publicAddress(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsValid() &&
+		!ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!ip.IsUnspecified() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// rejectInternalAddress is a net.Dialer Control hook. It runs after name
+// resolution for every connection, redirects included, so a public host
+// name resolving to an internal address is refused too.
+func rejectInternalAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !publicAddress(ip) {
+		return fmt.Errorf("connecting to %s is not allowed", ip)
+	}
+	return nil
+}
+
+// NewPublicClient returns a client that only connects to public addresses
+// and never goes through a proxy
+func NewPublicClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, Control: rejectInternalAddress}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       30 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+	}
+}
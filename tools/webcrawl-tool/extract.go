@@ -0,0 +1,168 @@
+package webcrawlTool
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// boilerplateElements never contain the main content of a page
+var boilerplateElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true,
+	atom.Form: true, atom.Svg: true, atom.Iframe: true, atom.Button: true,
+}
+
+var boilerplateRoles = map[string]bool{
+	"navigation": true, "banner": true, "contentinfo": true, "complementary": true, "search": true,
+}
+
+// blockElements start a new line in the extracted text
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.Br: true, atom.Li: true, atom.Ul: true, atom.Ol: true, atom.Tr: true, atom.Td: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Blockquote: true, atom.Pre: true, atom.Table: true, atom.Dd: true, atom.Dt: true,
+}
+
+type extractedPage struct {
+	title       string
+	description string
+	headings    []string
+	text        string
+	links       []string
+}
+
+// extract pulls the title, meta description, links and readable main
+// content out of an HTML document. Links are collected from the whole
+// document; text only from <main>/<article> when present, otherwise from
+// <body> minus navigation, headers, footers, scripts and similar.
+func extract(doc *html.Node) extractedPage {
+	page := extractedPage{}
+
+	var root, body *html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Title:
+				if page.title == "" {
+					page.title = collapseSpaces(textContent(n))
+				}
+			case atom.Meta:
+				name := strings.ToLower(attr(n, "name"))
+				if (name == "description" || strings.ToLower(attr(n, "property")) == "og:description") && page.description == "" {
+					page.description = collapseSpaces(attr(n, "content"))
+				}
+			case atom.A:
+				if href := strings.TrimSpace(attr(n, "href")); href != "" {
+					page.links = append(page.links, href)
+				}
+			case atom.Main, atom.Article:
+				if root == nil {
+					root = n
+				}
+			case atom.Body:
+				body = n
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+
+	if root == nil {
+		root = body
+	}
+	if root == nil {
+		root = doc
+	}
+
+	var builder strings.Builder
+	var render func(*html.Node)
+	render = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			builder.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			if isBoilerplate(n) {
+				return
+			}
+			switch n.DataAtom {
+			case atom.H1, atom.H2, atom.H3:
+				if heading := collapseSpaces(textContent(n)); heading != "" {
+					page.headings = append(page.headings, heading)
+				}
+			}
+			if blockElements[n.DataAtom] {
+				builder.WriteString("\n")
+				defer builder.WriteString("\n")
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			render(child)
+		}
+	}
+	render(root)
+
+	page.text = normalizeText(builder.String())
+	return page
+}
+
+func isBoilerplate(n *html.Node) bool {
+	if boilerplateElements[n.DataAtom] {
+		return true
+	}
+	if boilerplateRoles[strings.ToLower(attr(n, "role"))] {
+		return true
+	}
+	return attr(n, "aria-hidden") == "true" || hasAttr(n, "hidden")
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var builder strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		builder.WriteString(textContent(child))
+		builder.WriteString(" ")
+	}
+	return builder.String()
+}
+
+func collapseSpaces(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// normalizeText collapses whitespace within lines and drops empty lines
+func normalizeText(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = collapseSpaces(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,143 @@
+package webcrawlTool
+
+import (
+	"bufio"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type robotsRule struct {
+	allow   bool
+	length  int
+	pattern *regexp.Regexp
+}
+
+// robotsRules holds the robots.txt rules that apply to our user agent.
+type robotsRules struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+	disallowed bool
+}
+
+// allowAll is used when a site has no robots.txt
+var allowAll = &robotsRules{}
+
+// disallowAll is used when robots.txt cannot be fetched because of a server error
+var disallowAll = &robotsRules{disallowed: true}
+
+// parseRobots extracts the group matching userAgent, falling back to the
+// "*" group, from a robots.txt body.
+func parseRobots(body string, userAgent string) *robotsRules {
+	token := strings.ToLower(userAgent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+
+	type group struct {
+		agents []string
+		rules  robotsRules
+	}
+	var groups []*group
+	var current *group
+	inAgents := false
+
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				current = &group{}
+				groups = append(groups, current)
+				inAgents = true
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgents = false
+			// An empty rule matches nothing, e.g. "Disallow:" allows everything
+			if current == nil || value == "" {
+				continue
+			}
+			current.rules.rules = append(current.rules.rules, robotsRule{
+				allow:   key == "allow",
+				length:  len(value),
+				pattern: compileRobotsPattern(value),
+			})
+		case "crawl-delay":
+			inAgents = false
+			if current == nil {
+				continue
+			}
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.rules.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+
+	var wildcard *robotsRules
+	for _, g := range groups {
+		for _, agent := range g.agents {
+			if agent == "*" {
+				if wildcard == nil {
+					wildcard = &g.rules
+				}
+			} else if token != "" && strings.HasPrefix(token, agent) {
+				return &g.rules
+			}
+		}
+	}
+	if wildcard != nil {
+		return wildcard
+	}
+	return allowAll
+}
+
+// compileRobotsPattern turns a robots.txt path pattern, which may use "*"
+// and a trailing "$", into an anchored regular expression.
+func compileRobotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expression := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expression += "$"
+	}
+	return regexp.MustCompile(expression)
+}
+
+// Allowed reports whether path (including any query string) may be
+// fetched. The longest matching rule wins and Allow wins ties.
+func (r *robotsRules) Allowed(path string) bool {
+	if r.disallowed {
+		return false
+	}
+
+	allowed := true
+	longest := -1
+	for _, rule := range r.rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if rule.length > longest || (rule.length == longest && rule.allow) {
+			allowed = rule.allow
+			longest = rule.length
+		}
+	}
+	return allowed
+}
@@ -1,5 +1,284 @@
 package webcrawlTool
 
-func WebCrawl(url string) (string, error) {
-	return "", nil
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Page is the readable content of one crawled page.
+type Page struct {
+	URL         string   `json:"url"`
+	Depth       int      `json:"depth"`
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Headings    []string `json:"headings,omitempty"`
+	Text        string   `json:"text"`
+}
+
+// CrawlResult lists the pages fetched from a site, start page first.
+type CrawlResult struct {
+	StartURL string   `json:"start_url"`
+	Pages    []Page   `json:"pages"`
+	Skipped  []string `json:"skipped,omitempty"`
+}
+
+// Text joins the text of every page, each under its title and URL.
+func (r CrawlResult) Text() string {
+	sections := make([]string, 0, len(r.Pages))
+	for _, page := range r.Pages {
+		sections = append(sections, fmt.Sprintf("# %s (%s)\n%s", page.Title, page.URL, page.Text))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// skippedExtensions are never HTML pages
+var skippedExtensions = map[string]bool{
+	".pdf": true, ".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".svg": true,
+	".webp": true, ".ico": true, ".css": true, ".js": true, ".json": true, ".xml": true,
+	".zip": true, ".gz": true, ".mp4": true, ".mp3": true, ".woff": true, ".woff2": true,
+}
+
+type queuedPage struct {
+	url   *url.URL
+	depth int
+}
+
+type crawler struct {
+	options Options
+	client  *http.Client
+	robots  *robotsRules
+	delay   time.Duration
+	last    time.Time
+}
+
+// WebCrawl fetches startURL and the same-domain pages it links to,
+// breadth first, within the depth and page budget of options. It honours
+// robots.txt rules and crawl delays. Only a failure to fetch the start page
+// is returned as an error; other failing pages are listed as skipped.
+func WebCrawl(ctx context.Context, startURL string, options Options) (CrawlResult, error) {
+	start, err := normalizeURL(startURL)
+	if err != nil {
+		return CrawlResult{}, err
+	}
+
+	c := &crawler{options: options, client: sameSiteClient(options, start)}
+
+	c.robots = c.fetchRobots(ctx, start)
+	c.delay = max(options.Delay, min(c.robots.crawlDelay, options.Delay+options.MaxCrawlDelay))
+
+	result := CrawlResult{StartURL: start.String()}
+	if !c.robots.Allowed(requestPath(start)) {
+		return result, fmt.Errorf("crawling %s is disallowed by robots.txt", start)
+	}
+
+	visited := map[string]bool{start.String(): true}
+	queue := []queuedPage{{url: start}}
+
+	for len(queue) > 0 && (options.MaxPages <= 0 || len(result.Pages) < options.MaxPages) {
+		current := queue[0]
+		queue = queue[1:]
+
+		page, base, links, err := c.fetchPage(ctx, current)
+		if err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			if current.depth == 0 {
+				return result, err
+			}
+			result.Skipped = append(result.Skipped, current.url.String())
+			continue
+		}
+		result.Pages = append(result.Pages, page)
+		visited[base.String()] = true
+
+		if current.depth >= options.MaxDepth {
+			continue
+		}
+		for _, link := range links {
+			next, err := base.Parse(link)
+			if err != nil || !c.shouldVisit(start, next) {
+				continue
+			}
+			next.Fragment = ""
+			if visited[next.String()] {
+				continue
+			}
+			visited[next.String()] = true
+			if !c.robots.Allowed(requestPath(next)) {
+				result.Skipped = append(result.Skipped, next.String())
+				continue
+			}
+			queue = append(queue, queuedPage{url: next, depth: current.depth + 1})
+		}
+	}
+
+	return result, nil
+}
+
+func (c *crawler) shouldVisit(start, next *url.URL) bool {
+	if next.Scheme != "http" && next.Scheme != "https" {
+		return false
+	}
+	if !sameSite(start, next) {
+		return false
+	}
+	return !skippedExtensions[strings.ToLower(path.Ext(next.Path))]
+}
+
+// sameSiteClient returns a copy of the configured client that refuses to
+// follow redirects off the site of start
+func sameSiteClient(options Options, start *url.URL) *http.Client {
+	client := *NewPublicClient(options.Timeout)
+	if options.HTTPClient != nil {
+		client = *options.HTTPClient
+	}
+
+	checkRedirect := client.CheckRedirect
+	client.CheckRedirect = func(request *http.Request, via []*http.Request) error {
+		if (request.URL.Scheme != "http" && request.URL.Scheme != "https") || !sameSite(start, request.URL) {
+			return fmt.Errorf("redirect to %s leaves the site", request.URL)
+		}
+		if checkRedirect != nil {
+			return checkRedirect(request, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &client
+}
+
+func (c *crawler) fetchRobots(ctx context.Context, start *url.URL) *robotsRules {
+	robotsURL := &url.URL{Scheme: start.Scheme, Host: start.Host, Path: "/robots.txt"}
+	response, err := c.get(ctx, robotsURL)
+	if err != nil {
+		return allowAll
+	}
+	defer response.Body.Close()
+
+	switch {
+	case response.StatusCode >= 500:
+		return disallowAll
+	case response.StatusCode >= 400:
+		return allowAll
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, 512<<10))
+	if err != nil {
+		return allowAll
+	}
+	return parseRobots(string(body), c.options.UserAgent)
+}
+
+// fetchPage returns the page at current, the URL it was served from after
+// any redirects, and the links on it
+func (c *crawler) fetchPage(ctx context.Context, current queuedPage) (Page, *url.URL, []string, error) {
+	response, err := c.get(ctx, current.url)
+	if err != nil {
+		return Page{}, nil, nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return Page{}, nil, nil, fmt.Errorf("fetching %s: unexpected status %d", current.url, response.StatusCode)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type")); mediaType != "" && mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return Page{}, nil, nil, fmt.Errorf("fetching %s: not an HTML page (%s)", current.url, mediaType)
+	}
+
+	reader := io.Reader(response.Body)
+	if c.options.MaxBytesPerPage > 0 {
+		reader = io.LimitReader(reader, c.options.MaxBytesPerPage)
+	}
+	doc, err := html.Parse(reader)
+	if err != nil {
+		return Page{}, nil, nil, fmt.Errorf("parsing %s: %w", current.url, err)
+	}
+
+	extracted := extract(doc)
+	text := extracted.text
+	if runes := []rune(text); c.options.MaxTextLength > 0 && len(runes) > c.options.MaxTextLength {
+		text = string(runes[:c.options.MaxTextLength])
+	}
+
+	base := response.Request.URL
+	page := Page{
+		URL:         base.String(),
+		Depth:       current.depth,
+		Title:       extracted.title,
+		Description: extracted.description,
+		Headings:    extracted.headings,
+		Text:        text,
+	}
+	return page, base, extracted.links, nil
+}
+
+// get issues a GET request, waiting first so requests are at least the
+// crawl delay apart
+func (c *crawler) get(ctx context.Context, target *url.URL) (*http.Response, error) {
+	if !c.last.IsZero() {
+		if wait := c.delay - time.Since(c.last); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+	c.last = time.Now()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("User-Agent", c.options.UserAgent)
+	request.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.1")
+	return c.client.Do(request)
+}
+
+// normalizeURL parses a start URL, defaulting to https when no scheme is given
+func normalizeURL(rawURL string) (*url.URL, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return nil, errors.New("url is empty")
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid url: %s", rawURL)
+	}
+	parsed.Fragment = ""
+	if parsed.Path == "" {
+		parsed.Path = "/"
+	}
+	return parsed, nil
+}
+
+// sameSite treats www.example.com and example.com as the same domain
+func sameSite(a, b *url.URL) bool {
+	return strings.TrimPrefix(strings.ToLower(a.Host), "www.") == strings.TrimPrefix(strings.ToLower(b.Host), "www.")
+}
+
+func requestPath(u *url.URL) string {
+	return u.RequestURI()
 }
@@ -0,0 +1,291 @@
+package webcrawlTool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newFixtureSite(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata/site")))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testOptions() Options {
+	options := DefaultOptions()
+	options.Delay = 0
+	// The fixture sites listen on loopback, which the default client refuses
+	options.HTTPClient = &http.Client{Timeout: options.Timeout}
+	return options
+}
+
+func pageURLs(result CrawlResult) []string {
+	urls := make([]string, len(result.Pages))
+	for i, page := range result.Pages {
+		urls[i] = page.URL
+	}
+	return urls
+}
+
+func TestWebCrawlFollowsSameDomainLinks(t *testing.T) {
+	server := newFixtureSite(t)
+
+	result, err := WebCrawl(context.Background(), server.URL, testOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		server.URL + "/",
+		server.URL + "/about.html",
+		server.URL + "/products.html",
+		server.URL + "/team.html",
+	}
+	got := pageURLs(result)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected pages %v, got %v", want, got)
+	}
+	if result.Pages[3].Depth != 2 {
+		t.Errorf("expected team page at depth 2, got %d", result.Pages[3].Depth)
+	}
+}
+
+func TestWebCrawlHonoursRobotsTxt(t *testing.T) {
+	server := newFixtureSite(t)
+
+	result, err := WebCrawl(context.Background(), server.URL, testOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, page := range result.Pages {
+		if strings.Contains(page.URL, "/private/") {
+			t.Errorf("crawled disallowed page %s", page.URL)
+		}
+	}
+	if len(result.Skipped) != 1 || !strings.HasSuffix(result.Skipped[0], "/private/secret.html") {
+		t.Errorf("expected private page to be skipped, got %v", result.Skipped)
+	}
+
+	options := testOptions()
+	options.UserAgent = "OtherBot/2.0"
+	if _, err := WebCrawl(context.Background(), server.URL, options); err == nil {
+		t.Errorf("expected an error for a user agent disallowed from the whole site")
+	}
+}
+
+func TestWebCrawlAppliesCrawlDelay(t *testing.T) {
+	server := newFixtureSite(t)
+
+	started := time.Now()
+	result, err := WebCrawl(context.Background(), server.URL, testOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// robots.txt plus every page, each at least 10ms after the previous request
+	minimum := time.Duration(len(result.Pages)) * 10 * time.Millisecond
+	if elapsed := time.Since(started); elapsed < minimum {
+		t.Errorf("expected crawl to take at least %v, took %v", minimum, elapsed)
+	}
+}
+
+func TestWebCrawlCapsCrawlDelay(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("User-agent: *\nCrawl-delay: 3600\n"))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><p>Home</p><a href="/next">Next</a></body></html>`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	options := testOptions()
+	options.MaxCrawlDelay = 10 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := WebCrawl(ctx, server.URL, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Pages) != 2 {
+		t.Errorf("expected both pages despite an hour long Crawl-delay, got %v", pageURLs(result))
+	}
+}
+
+func TestWebCrawlStaysOnSiteAcrossRedirects(t *testing.T) {
+	offsite := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("expected no request off the site, got %s", r.URL)
+	}))
+	t.Cleanup(offsite.Close)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/docs/", http.StatusFound)
+	})
+	mux.HandleFunc("/docs/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><a href="guide.html">Guide</a><a href="/away">Away</a></body></html>`))
+	})
+	mux.HandleFunc("/docs/guide.html", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body><p>Guide</p></body></html>`))
+	})
+	mux.HandleFunc("/away", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, offsite.URL+"/", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	result, err := WebCrawl(context.Background(), server.URL, testOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{server.URL + "/docs/", server.URL + "/docs/guide.html"}
+	if got := pageURLs(result); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected links resolved against the redirected URL %v, got %v", want, got)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0] != server.URL+"/away" {
+		t.Errorf("expected the off-site redirect to be skipped, got %v", result.Skipped)
+	}
+}
+
+func TestWebCrawlRespectsBudgets(t *testing.T) {
+	server := newFixtureSite(t)
+
+	options := testOptions()
+	options.MaxDepth = 0
+	result, err := WebCrawl(context.Background(), server.URL, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Pages) != 1 {
+		t.Errorf("expected only the start page at depth 0, got %v", pageURLs(result))
+	}
+
+	options = testOptions()
+	options.MaxPages = 2
+	result, err = WebCrawl(context.Background(), server.URL, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Pages) != 2 {
+		t.Errorf("expected 2 pages, got %v", pageURLs(result))
+	}
+}
+
+func TestWebCrawlExtractsMainContent(t *testing.T) {
+	server := newFixtureSite(t)
+
+	options := testOptions()
+	options.MaxDepth = 1
+	result, err := WebCrawl(context.Background(), server.URL, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	home := result.Pages[0]
+	if home.Title != "Acme Cards | Digital business cards" {
+		t.Errorf("unexpected title %q", home.Title)
+	}
+	if home.Description != "Digital business cards for modern sales teams." {
+		t.Errorf("unexpected description %q", home.Description)
+	}
+	wantText := "Share your contact details in one tap\nAcme Cards replaces paper business cards with NFC cards and a mobile app."
+	if home.Text != wantText {
+		t.Errorf("unexpected text %q", home.Text)
+	}
+	if len(home.Headings) != 1 || home.Headings[0] != "Share your contact details in one tap" {
+		t.Errorf("unexpected headings %v", home.Headings)
+	}
+
+	products := result.Pages[2]
+	if strings.Contains(products.Text, "Home") {
+		t.Errorf("expected navigation to be dropped from %q", products.Text)
+	}
+	if !strings.Contains(products.Text, "Acme Card Pro\nAcme Card Business") {
+		t.Errorf("expected list items on separate lines, got %q", products.Text)
+	}
+}
+
+func TestWebCrawlStartPageErrors(t *testing.T) {
+	server := newFixtureSite(t)
+
+	if _, err := WebCrawl(context.Background(), server.URL+"/missing.html", testOptions()); err == nil {
+		t.Errorf("expected an error for a missing start page")
+	}
+	if _, err := WebCrawl(context.Background(), "", testOptions()); err == nil {
+		t.Errorf("expected an error for an empty url")
+	}
+	if _, err := WebCrawl(context.Background(), "ftp://example.com", testOptions()); err == nil {
+		t.Errorf("expected an error for a non-http url")
+	}
+}
+
+func TestWebCrawlRefusesInternalAddresses(t *testing.T) {
+	server := newFixtureSite(t)
+
+	options := DefaultOptions()
+	options.Delay = 0
+	if _, err := WebCrawl(context.Background(), server.URL, options); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("expected the default client to refuse a loopback site, got %v", err)
+	}
+}
+
+func TestPublicAddress(t *testing.T) {
+	tests := map[string]bool{
+		"93.184.216.34":          true,
+		"2606:2800:220:1::1":     true,
+		"127.0.0.1":              false,
+		"::1":                    false,
+		"10.1.2.3":               false,
+		"172.16.0.1":             false,
+		"192.168.1.1":            false,
+		"169.254.169.254":        false,
+		"fe80::1":                false,
+		"fd00:ec2::254":          false,
+		"100.64.0.1":             false,
+		"0.0.0.0":                false,
+		"::ffff:127.0.0.1":       false,
+		"::ffff:169.254.169.254": false,
+		"224.0.0.1":              false,
+	}
+	for address, want := range tests {
+		if got := publicAddress(netip.MustParseAddr(address)); got != want {
+			t.Errorf("publicAddress(%s) = %v, want %v", address, got, want)
+		}
+	}
+}
+
+func TestRobotsRules(t *testing.T) {
+	rules := parseRobots(`
+User-agent: *
+Disallow: /admin
+Allow: /admin/public
+Disallow: /*.php$
+Disallow:
+`, "MobiloAIBot/1.0")
+
+	tests := map[string]bool{
+		"/":                   true,
+		"/admin":              false,
+		"/admin/settings":     false,
+		"/admin/public/index": true,
+		"/index.php":          false,
+		"/index.php?x=1":      true,
+	}
+	for path, want := range tests {
+		if got := rules.Allowed(path); got != want {
+			t.Errorf("Allowed(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
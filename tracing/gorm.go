@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+type gormPlugin struct{}
+
+// GormPlugin traces every query run with a context that carries a span,
+// i.e. queries run via db.WithContext on behalf of a traced request or
+// job. Queries without one, such as the job queue polling, are not traced.
+// Spans carry the SQL with placeholders, never the values bound to them.
+func GormPlugin() gorm.Plugin {
+	return gormPlugin{}
+}
+
+func (gormPlugin) Name() string {
+	return "tracing"
+}
+
+func (plugin gormPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	for _, err := range []error{
+		callback.Create().Before("*").Register("tracing:before_create", plugin.start("create")),
+		callback.Create().After("*").Register("tracing:after_create", plugin.end),
+		callback.Query().Before("*").Register("tracing:before_query", plugin.start("query")),
+		callback.Query().After("*").Register("tracing:after_query", plugin.end),
+		callback.Update().Before("*").Register("tracing:before_update", plugin.start("update")),
+		callback.Update().After("*").Register("tracing:after_update", plugin.end),
+		callback.Delete().Before("*").Register("tracing:before_delete", plugin.start("delete")),
+		callback.Delete().After("*").Register("tracing:after_delete", plugin.end),
+		callback.Row().Before("*").Register("tracing:before_row", plugin.start("row")),
+		callback.Row().After("*").Register("tracing:after_row", plugin.end),
+		callback.Raw().Before("*").Register("tracing:before_raw", plugin.start("raw")),
+		callback.Raw().After("*").Register("tracing:after_raw", plugin.end),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (gormPlugin) start(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		if ctx == nil || !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+			return
+		}
+		_, span := tracer().Start(ctx, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemPostgreSQL),
+		)
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func (gormPlugin) end(db *gorm.DB) {
+	value, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	span.SetAttributes(
+		semconv.DBQueryText(db.Statement.SQL.String()),
+		semconv.DBCollectionName(db.Statement.Table),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	if !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		RecordError(span, db.Error)
+	}
+	span.End()
+}
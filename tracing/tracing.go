@@ -0,0 +1,107 @@
+// Package tracing sets up OpenTelemetry tracing of HTTP requests, database
+// queries and model calls. Trace context is propagated from callers with
+// the W3C traceparent and tracestate headers.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"go-server/config"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "go-server"
+
+// Setup installs the global propagator and, when an OTLP endpoint is
+// configured, a tracer provider exporting to it. The returned function
+// flushes pending spans and must be called on shutdown.
+func Setup(ctx context.Context, tracingConfig *config.TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if tracingConfig.OTLPEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(tracingConfig.OTLPEndpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(tracingConfig.ServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sampler(tracingConfig.SampleRatio)),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// sampler keeps the given ratio of new traces and follows the caller's
+// sampling decision for continued ones
+func sampler(ratio float64) sdktrace.Sampler {
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Middleware starts a span for every request, continuing the caller's
+// trace when the request carries one
+func Middleware(serviceName string) gin.HandlerFunc {
+	return otelgin.Middleware(serviceName)
+}
+
+// Start starts a span named name as a child of the span in ctx
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// StartLLMCall starts the span of a model call
+func StartLLMCall(ctx context.Context, model string, channel string) (context.Context, trace.Span) {
+	return tracer().Start(ctx, "llm.call",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("gen_ai.request.model", model),
+			attribute.String("mobilo.channel", channel),
+		),
+	)
+}
+
+// EndLLMCall records the token counts and outcome of a model call and ends
+// its span
+func EndLLMCall(span trace.Span, promptTokens int64, completionTokens int64, err error) {
+	span.SetAttributes(
+		attribute.Int64("gen_ai.usage.input_tokens", promptTokens),
+		attribute.Int64("gen_ai.usage.output_tokens", completionTokens),
+	)
+	RecordError(span, err)
+	span.End()
+}
+
+// RecordError marks span as failed with err; a nil err is ignored
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,146 @@
+package tracing
+
+import (
+	"go-server/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+// recordSpans installs a tracer provider recording every ended span in
+// memory, sampled at ratio, for the duration of the test
+func recordSpans(t *testing.T, ratio float64) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder), sdktrace.WithSampler(sampler(ratio)))
+
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return recorder
+}
+
+// newTracedRouter serves GET /widgets with a query and an insert through a
+// GORM connection that builds SQL without running it
+func newTracedRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	db, err := gorm.Open(postgres.Open("host=localhost dbname=tracing_test"), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 gormLogger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.Use(GormPlugin()); err != nil {
+		t.Fatalf("failed to install the plugin: %v", err)
+	}
+
+	type widget struct {
+		ID   int
+		Name string
+	}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware("tracing-test"))
+	router.GET("/widgets", func(c *gin.Context) {
+		var widgets []widget
+		db.WithContext(c.Request.Context()).Where("name = ?", "secret").Find(&widgets)
+		db.WithContext(c.Request.Context()).Create(&widget{Name: "secret"})
+		c.JSON(http.StatusOK, widgets)
+	})
+	return router
+}
+
+func TestRequestSpanHasDatabaseChildSpans(t *testing.T) {
+	recorder := recordSpans(t, 1)
+	router := newTracedRouter(t)
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/widgets", nil))
+	if response.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", response.Code, response.Body.String())
+	}
+
+	spans := recorder.Ended()
+	var request sdktrace.ReadOnlySpan
+	for _, span := range spans {
+		if span.SpanKind() == trace.SpanKindServer {
+			request = span
+		}
+	}
+	if request == nil {
+		t.Fatalf("expected a server span for the request, got %d spans", len(spans))
+	}
+
+	children := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range spans {
+		if span.Parent().SpanID() == request.SpanContext().SpanID() {
+			children[span.Name()] = span
+		}
+	}
+	for _, name := range []string{"gorm.query", "gorm.create"} {
+		child, ok := children[name]
+		if !ok {
+			t.Errorf("expected a %s child span of the request, got %v", name, children)
+			continue
+		}
+		if child.SpanKind() != trace.SpanKindClient || child.SpanContext().TraceID() != request.SpanContext().TraceID() {
+			t.Errorf("expected %s to be a client span in the request's trace", name)
+		}
+		var query string
+		for _, attribute := range child.Attributes() {
+			if attribute.Key == "db.query.text" {
+				query = attribute.Value.AsString()
+			}
+			if attribute.Value.AsString() == "secret" {
+				t.Errorf("expected %s not to carry bound values, got %s", name, attribute.Key)
+			}
+		}
+		if !strings.Contains(query, `"widgets"`) {
+			t.Errorf("expected %s to carry its SQL, got %q", name, query)
+		}
+	}
+}
+
+func TestSamplerFollowsTheConfiguredRatioAndTheCaller(t *testing.T) {
+	t.Setenv("OTEL_TRACES_SAMPLER_ARG", "0")
+	recorder := recordSpans(t, config.LoadTracingConfig().SampleRatio)
+	router := newTracedRouter(t)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/widgets", nil))
+	if spans := recorder.Ended(); len(spans) != 0 {
+		t.Fatalf("expected a new trace not to be sampled at ratio 0, got %d spans", len(spans))
+	}
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	request := httptest.NewRequest(http.MethodGet, "/widgets", nil)
+	request.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected the request and its queries to follow the caller's sampling, got %d spans", len(spans))
+	}
+	for _, span := range spans {
+		if span.SpanContext().TraceID().String() != traceID {
+			t.Errorf("expected %s to continue the caller's trace, got %s", span.Name(), span.SpanContext().TraceID())
+		}
+	}
+}